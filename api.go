@@ -0,0 +1,77 @@
+package dropbox
+
+import (
+	"context"
+)
+
+// FilesAPI is the interface implemented by Files, which may be replaced by
+// a mock to test code using Client.Files, see the dropboxmock package.
+type FilesAPI interface {
+	GetMetadata(ctx context.Context, in *GetMetadataInput) (*GetMetadataOutput, error)
+	CreateFolder(ctx context.Context, in *CreateFolderInput) (*CreateFolderOutput, error)
+	Delete(ctx context.Context, in *DeleteInput) (*DeleteOutput, error)
+	PermanentlyDelete(ctx context.Context, in *PermanentlyDeleteInput) error
+	Copy(ctx context.Context, in *CopyInput) (*CopyOutput, error)
+	Move(ctx context.Context, in *MoveInput) (*MoveOutput, error)
+	Restore(ctx context.Context, in *RestoreInput) (*RestoreOutput, error)
+	ListFolder(ctx context.Context, in *ListFolderInput) (*ListFolderOutput, error)
+	ListFolderContinue(ctx context.Context, in *ListFolderContinueInput) (*ListFolderOutput, error)
+	ListFolderIterator(in *ListFolderInput) *Iterator[Metadata]
+	GetLatestCursor(ctx context.Context, in *ListFolderInput) (*GetLatestCursorOutput, error)
+	ListFolderLongpoll(ctx context.Context, in *ListFolderLongpollInput) (*ListFolderLongpollOutput, error)
+	Search(ctx context.Context, in *SearchInput) (*SearchOutput, error)
+	Upload(ctx context.Context, in *UploadInput) (*UploadOutput, error)
+	UploadSessionStart(ctx context.Context, in *UploadSessionStartInput) (*UploadSessionStartOutput, error)
+	UploadSessionAppend(ctx context.Context, in *UploadSessionAppendInput) error
+	UploadSessionFinish(ctx context.Context, in *UploadSessionFinishInput) (*UploadOutput, error)
+	UploadSessionFinishBatch(ctx context.Context, in *UploadSessionFinishBatchInput) (*UploadSessionFinishBatchOutput, error)
+	UploadSessionFinishBatchCheck(ctx context.Context, in *AsyncJobInput) (*UploadSessionFinishBatchOutput, error)
+	UploadLarge(ctx context.Context, in *UploadLargeInput) (*UploadOutput, error)
+	UploadBatch(ctx context.Context, in *UploadBatchInput) (*UploadBatchOutput, error)
+	Download(ctx context.Context, in *DownloadInput) (*DownloadOutput, error)
+	ResumeDownload(ctx context.Context, in *ResumeDownloadInput) (*ResumeDownloadOutput, error)
+	GetThumbnail(ctx context.Context, in *GetThumbnailInput) (*GetThumbnailOutput, error)
+	GetPreview(ctx context.Context, in *GetPreviewInput) (*GetPreviewOutput, error)
+	ListRevisions(ctx context.Context, in *ListRevisionsInput) (*ListRevisionsOutput, error)
+}
+
+// SharingAPI is the interface implemented by Sharing.
+type SharingAPI interface {
+	CreateSharedLink(ctx context.Context, in *CreateSharedLinkInput) (*CreateSharedLinkOutput, error)
+	ListSharedFileMembers(ctx context.Context, in *ListSharedFileMembersInput) (*ListSharedMembersOutput, error)
+	ListSharedFileMembersContinue(ctx context.Context, in *ListSharedMembersContinueInput) (*ListSharedMembersOutput, error)
+	ListSharedFileMembersIterator(in *ListSharedFileMembersInput) *Iterator[SharedMember]
+	ListSharedFolderMembers(ctx context.Context, in *ListSharedFolderMembersInput) (*ListSharedMembersOutput, error)
+	ListSharedFolderMembersContinue(ctx context.Context, in *ListSharedMembersContinueInput) (*ListSharedMembersOutput, error)
+	ListSharedFolderMembersIterator(in *ListSharedFolderMembersInput) *Iterator[SharedMember]
+	ListSharedFolders(ctx context.Context, in *ListSharedFolderInput) (*ListSharedFolderOutput, error)
+	ListSharedFoldersContinue(ctx context.Context, in *ListSharedFolderContinueInput) (*ListSharedFolderOutput, error)
+	ListSharedFoldersIterator(in *ListSharedFolderInput) *Iterator[SharedFolderMetadata]
+}
+
+// UsersAPI is the interface implemented by Users.
+type UsersAPI interface {
+	GetAccount(ctx context.Context, in *GetAccountInput) (*GetAccountOutput, error)
+	GetAccountBatch(ctx context.Context, in *GetAccountBatchInput) (GetAccountBatchOutput, error)
+	GetCurrentAccount(ctx context.Context) (*GetCurrentAccountOutput, error)
+	GetSpaceUsage(ctx context.Context) (*GetSpaceUsageOutput, error)
+}
+
+// PaperAPI is the interface implemented by Paper.
+type PaperAPI interface {
+	ListDocs(ctx context.Context, in *PaperDocsListInput) (*PaperDocsListOutput, error)
+	ListDocsContinue(ctx context.Context, in *PaperDocsListContinueInput) (*PaperDocsListOutput, error)
+	ListDocsIterator(in *PaperDocsListInput) *Iterator[string]
+	Download(ctx context.Context, in *PaperDownloadInput) (*PaperDownloadOutput, error)
+	GetFolderInfo(ctx context.Context, in *PaperGetFolderInfoInput) (*PaperGetFolderInfoOutput, error)
+	Create(ctx context.Context, in *PaperCreateInput) (*PaperCreateOutput, error)
+	PermanentlyDelete(ctx context.Context, in *PaperPermanentlyDeleteInput) error
+	AlphaGetMetadata(ctx context.Context, in *PaperGetMetadataInput) (*PaperGetMetadataOutput, error)
+}
+
+var (
+	_ FilesAPI   = (*Files)(nil)
+	_ SharingAPI = (*Sharing)(nil)
+	_ UsersAPI   = (*Users)(nil)
+	_ PaperAPI   = (*Paper)(nil)
+)
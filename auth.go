@@ -0,0 +1,344 @@
+package dropbox
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Auth client for the access token itself.
+type Auth struct {
+	*Client
+}
+
+// NewAuth client.
+func NewAuth(config *Config) *Auth {
+	return &Auth{
+		Client: &Client{
+			Config: config,
+		},
+	}
+}
+
+// RevokeToken disables the access token used to authenticate the call. When
+// the token was obtained with offline access, its refresh token is revoked
+// as well.
+func (c *Auth) RevokeToken(ctx context.Context) (err error) {
+	body, err := c.call(ctx, "/auth/token/revoke", nil)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	return
+}
+
+// OAuthConfig describes a Dropbox app for the OAuth2 authorization code flow.
+// AppSecret is left empty for apps which cannot keep a secret, such as CLI
+// tools, in which case every flow must use PKCE.
+// doc: https://developers.dropbox.com/oauth-guide
+type OAuthConfig struct {
+	HTTPClient    *http.Client
+	Endpoints     *Endpoints // defaults to DefaultEndpoints when nil
+	AppKey        string
+	AppSecret     string
+	RedirectURL   string   // left empty when the user pastes the code into the app
+	Scopes        []string // defaults to the scopes configured for the app
+	OfflineAccess bool     // request a refresh token along with the access token
+}
+
+// PKCE holds the code verifier for a single authorization, and the challenge
+// derived from it which is sent along with the authorize URL.
+type PKCE struct {
+	Verifier  string
+	Challenge string
+}
+
+// NewPKCE returns a random code verifier and its S256 challenge.
+func NewPKCE() (*PKCE, error) {
+	verifier, err := randomString(64)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return &PKCE{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// NewState returns a random state value to protect the redirect from
+// cross-site request forgery.
+func NewState() (string, error) {
+	return randomString(32)
+}
+
+// AuthCodeURL returns the URL of the page where the user authorizes the app.
+// The pkce is optional for apps with a secret, and must be passed to Exchange
+// along with the resulting code.
+func (o *OAuthConfig) AuthCodeURL(state string, pkce *PKCE) string {
+	v := url.Values{
+		"client_id":     {o.AppKey},
+		"response_type": {"code"},
+	}
+	if o.RedirectURL != "" {
+		v.Set("redirect_uri", o.RedirectURL)
+	}
+	if state != "" {
+		v.Set("state", state)
+	}
+	if o.OfflineAccess {
+		v.Set("token_access_type", "offline")
+	}
+	if len(o.Scopes) > 0 {
+		v.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	if pkce != nil {
+		v.Set("code_challenge", pkce.Challenge)
+		v.Set("code_challenge_method", "S256")
+	}
+
+	return o.endpoints().Web + "/oauth2/authorize?" + v.Encode()
+}
+
+// Exchange the authorization code for a token. The pkce must be the one used
+// to build the authorize URL, or nil if none was used.
+func (o *OAuthConfig) Exchange(ctx context.Context, code string, pkce *PKCE) (*Token, error) {
+	form := url.Values{
+		"grant_type": {"authorization_code"},
+		"code":       {code},
+		"client_id":  {o.AppKey},
+	}
+	if o.AppSecret != "" {
+		form.Set("client_secret", o.AppSecret)
+	}
+	if o.RedirectURL != "" {
+		form.Set("redirect_uri", o.RedirectURL)
+	}
+	if pkce != nil {
+		form.Set("code_verifier", pkce.Verifier)
+	}
+
+	return requestToken(ctx, o.httpClient(), o.endpoints(), form)
+}
+
+// Config returns a Config for New which authenticates with the token. Tokens
+// with a refresh token are refreshed automatically as they expire.
+func (o *OAuthConfig) Config(token *Token) *Config {
+	config := NewConfig(token.AccessToken)
+	config.HTTPClient = o.httpClient()
+	config.Endpoints = o.Endpoints
+
+	if token.RefreshToken != "" {
+		source := NewRefreshTokenSource(o.AppKey, o.AppSecret, token.RefreshToken)
+		source.HTTPClient = o.httpClient()
+		source.Endpoints = o.Endpoints
+		source.token = token
+		config.TokenSource = source
+	}
+
+	return config
+}
+
+// AuthorizeLoopback runs the whole authorization flow for a CLI tool. It
+// listens for the redirect on the loopback address addr, such as
+// "127.0.0.1:53682", which must match a redirect URI registered for the app.
+// The authorize URL is passed to open, which typically launches a browser or
+// prints the URL for the user.
+func (o *OAuthConfig) AuthorizeLoopback(ctx context.Context, addr string, open func(url string) error) (*Config, error) {
+	l, err := NewLoopbackListener(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+
+	state, err := NewState()
+	if err != nil {
+		return nil, err
+	}
+
+	pkce, err := NewPKCE()
+	if err != nil {
+		return nil, err
+	}
+
+	flow := *o
+	flow.RedirectURL = l.RedirectURL
+
+	if err := open(flow.AuthCodeURL(state, pkce)); err != nil {
+		return nil, err
+	}
+
+	code, err := l.Wait(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := flow.Exchange(ctx, code, pkce)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Config(token), nil
+}
+
+func (o *OAuthConfig) httpClient() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (o *OAuthConfig) endpoints() *Endpoints {
+	if o.Endpoints != nil {
+		return o.Endpoints
+	}
+	return DefaultEndpoints
+}
+
+// LoopbackListener serves the redirect URI on a loopback address and captures
+// the authorization code from the first redirect which carries the expected
+// state.
+type LoopbackListener struct {
+	RedirectURL string
+
+	listener  net.Listener
+	server    *http.Server
+	redirects chan *redirect
+	done      chan struct{}
+	once      sync.Once
+}
+
+// redirect received by a LoopbackListener, which Wait accepts or rejects.
+type redirect struct {
+	query    url.Values
+	accepted chan bool
+	answered chan struct{} // closed once the browser has its response
+}
+
+// NewLoopbackListener starts listening on the loopback address addr, whose
+// host must be 127.0.0.1, ::1 or localhost. Pass a port of 0 to pick a free
+// port, for apps which register no redirect URIs.
+func NewLoopbackListener(addr string) (*LoopbackListener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !isLoopback(host) {
+		return nil, fmt.Errorf("dropbox: oauth2 redirect address %q is not a loopback address", addr)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &LoopbackListener{
+		RedirectURL: "http://" + listener.Addr().String() + "/",
+		listener:    listener,
+		redirects:   make(chan *redirect),
+		done:        make(chan struct{}),
+	}
+	l.server = &http.Server{Handler: http.HandlerFunc(l.serveHTTP)}
+	go l.server.Serve(listener)
+
+	return l, nil
+}
+
+// isLoopback reports whether host only accepts connections from this machine.
+func isLoopback(host string) bool {
+	switch host {
+	case "127.0.0.1", "::1", "localhost":
+		return true
+	}
+	return false
+}
+
+// serveHTTP hands each redirect to Wait, and answers it once Wait has checked
+// its state, so that a stray or forged request does not end the flow.
+func (l *LoopbackListener) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("code") == "" && q.Get("error") == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	rd := &redirect{query: q, accepted: make(chan bool, 1), answered: make(chan struct{})}
+	defer close(rd.answered)
+
+	select {
+	case l.redirects <- rd:
+	case <-l.done:
+		http.Error(w, "Authorization already received.", http.StatusConflict)
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	if !<-rd.accepted {
+		http.Error(w, "Authorization state mismatch.", http.StatusBadRequest)
+		return
+	}
+	const msg = "Authorization complete, you may close this window.\n"
+	w.Header().Set("Content-Length", strconv.Itoa(len(msg)))
+	io.WriteString(w, msg)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Wait for the redirect carrying the expected state and return its code.
+// Redirects with any other state are rejected, and waiting continues.
+func (l *LoopbackListener) Wait(ctx context.Context, state string) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case rd := <-l.redirects:
+			q := rd.query
+			if q.Get("state") != state {
+				rd.accepted <- false
+				continue
+			}
+
+			rd.accepted <- true
+			l.once.Do(func() { close(l.done) })
+
+			// Let the browser have its response before the listener may be
+			// closed.
+			select {
+			case <-rd.answered:
+			case <-ctx.Done():
+			}
+
+			if code := q.Get("error"); code != "" {
+				return "", &TokenError{Code: code, Description: q.Get("error_description")}
+			}
+			return q.Get("code"), nil
+		}
+	}
+}
+
+// Close stops listening.
+func (l *LoopbackListener) Close() error {
+	return l.server.Close()
+}
+
+// randomString returns n random bytes as unpadded URL-safe base64.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("dropbox: reading random bytes: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package dropbox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_RevokeToken(t *testing.T) {
+	var path string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "null")
+	})
+
+	assert.NoError(t, c.Auth.RevokeToken(ctx))
+	assert.Equal(t, "/2/auth/token/revoke", path)
+}
+
+func TestOAuthConfig_AuthCodeURL(t *testing.T) {
+	o := &OAuthConfig{
+		AppKey:        "key",
+		RedirectURL:   "http://localhost:8080/",
+		Scopes:        []string{"files.content.read", "files.content.write"},
+		OfflineAccess: true,
+	}
+
+	pkce, err := NewPKCE()
+	require.NoError(t, err)
+
+	u, err := url.Parse(o.AuthCodeURL("state", pkce))
+	require.NoError(t, err)
+	assert.Equal(t, "www.dropbox.com", u.Host)
+	assert.Equal(t, "/oauth2/authorize", u.Path)
+
+	q := u.Query()
+	assert.Equal(t, "key", q.Get("client_id"))
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "http://localhost:8080/", q.Get("redirect_uri"))
+	assert.Equal(t, "state", q.Get("state"))
+	assert.Equal(t, "offline", q.Get("token_access_type"))
+	assert.Equal(t, "files.content.read files.content.write", q.Get("scope"))
+	assert.Equal(t, pkce.Challenge, q.Get("code_challenge"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+}
+
+func TestOAuthConfig_AuthorizeLoopback(t *testing.T) {
+	var pkce url.Values
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/token":
+			assert.Equal(t, "authorization_code", r.FormValue("grant_type"))
+			assert.Equal(t, "code", r.FormValue("code"))
+			assert.Equal(t, "key", r.FormValue("client_id"))
+			assert.Empty(t, r.FormValue("client_secret"))
+			assert.NotEmpty(t, r.FormValue("code_verifier"))
+			assert.NotEmpty(t, pkce.Get("code_challenge"))
+			io.WriteString(w, `{"access_token": "access", "refresh_token": "refresh", "expires_in": 14400}`)
+		case "/2/users/get_current_account":
+			assert.Equal(t, "Bearer access", r.Header.Get("Authorization"))
+			io.WriteString(w, `{"account_id": "dbid:1"}`)
+		}
+	}))
+	defer s.Close()
+
+	o := &OAuthConfig{
+		Endpoints:     NewEndpoints(s.URL),
+		AppKey:        "key",
+		OfflineAccess: true,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	config, err := o.AuthorizeLoopback(ctx, "127.0.0.1:0", func(authorize string) error {
+		u, err := url.Parse(authorize)
+		if err != nil {
+			return err
+		}
+		pkce = u.Query()
+
+		// Play the part of the browser following the redirect.
+		redirect := pkce.Get("redirect_uri") + "?code=code&state=" + url.QueryEscape(pkce.Get("state"))
+		go func() {
+			res, err := http.Get(redirect)
+			if assert.NoError(t, err) {
+				res.Body.Close()
+			}
+		}()
+		return nil
+	})
+	require.NoError(t, err)
+	require.IsType(t, &RefreshTokenSource{}, config.TokenSource)
+
+	out, err := New(config).Users.GetCurrentAccount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "dbid:1", out.AccountID)
+}
+
+func TestLoopbackListener_error(t *testing.T) {
+	l, err := NewLoopbackListener("127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		res, err := http.Get(l.RedirectURL + "?error=access_denied&error_description=denied&state=state")
+		if assert.NoError(t, err) {
+			res.Body.Close()
+		}
+	}()
+
+	_, err = l.Wait(ctx, "state")
+	require.Error(t, err)
+	assert.Equal(t, "access_denied", err.(*TokenError).Code)
+}
+
+func TestLoopbackListener_state(t *testing.T) {
+	l, err := NewLoopbackListener("127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	statuses := make(chan int, 3)
+	get := func(query string) {
+		res, err := http.Get(l.RedirectURL + query)
+		if assert.NoError(t, err) {
+			res.Body.Close()
+			statuses <- res.StatusCode
+		}
+	}
+
+	go func() {
+		get("?code=forged&state=other")
+		get("?code=code&state=state")
+		get("?code=late&state=state")
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	code, err := l.Wait(ctx, "state")
+	require.NoError(t, err)
+	assert.Equal(t, "code", code)
+
+	assert.Equal(t, http.StatusBadRequest, <-statuses)
+	assert.Equal(t, http.StatusOK, <-statuses)
+	assert.Equal(t, http.StatusConflict, <-statuses)
+}
+
+func TestNewLoopbackListener_address(t *testing.T) {
+	for _, addr := range []string{":0", "0.0.0.0:0", "[::]:0", "example.com:0"} {
+		_, err := NewLoopbackListener(addr)
+		assert.Error(t, err, addr)
+	}
+
+	for _, addr := range []string{"127.0.0.1:0", "localhost:0"} {
+		l, err := NewLoopbackListener(addr)
+		require.NoError(t, err, addr)
+		l.Close()
+	}
+}
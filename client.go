@@ -9,25 +9,36 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // Client implements a Dropbox client. You may use the Files and Users
-// clients directly if preferred, however Client exposes them both.
+// clients directly if preferred, however Client exposes them both. Users,
+// Files, Sharing and Paper are interfaces, so that they may be replaced by
+// mocks in tests.
 type Client struct {
 	*Config
-	Users   *Users
-	Files   *Files
-	Sharing *Sharing
-	Paper   *Paper
+	Auth    *Auth
+	Users   UsersAPI
+	Files   FilesAPI
+	Sharing SharingAPI
+	Paper   PaperAPI
+	Team    *Team
+
+	longpoll backoff
 }
 
 // New client.
 func New(config *Config) *Client {
 	c := &Client{Config: config}
+	c.Auth = &Auth{c}
 	c.Users = &Users{c}
 	c.Files = &Files{c}
 	c.Sharing = &Sharing{c}
 	c.Paper = &Paper{c}
+	c.Team = &Team{c}
 	return c
 }
 
@@ -37,7 +48,18 @@ func (c *Client) call(
 	path string,
 	in interface{},
 ) (io.ReadCloser, error) {
-	url := "https://api.dropboxapi.com/2" + path
+	return c.rpc(ctx, "api", path, in)
+}
+
+// rpc style endpoint on the given subdomain. Requests to the notify host do
+// not require authorization, so none is sent.
+func (c *Client) rpc(
+	ctx context.Context,
+	subdomain string,
+	path string,
+	in interface{},
+) (io.ReadCloser, error) {
+	url := c.endpoints().host(subdomain) + "/2" + path
 
 	body, err := json.Marshal(in)
 	if err != nil {
@@ -49,18 +71,18 @@ func (c *Client) call(
 		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
 	req.Header.Set("Content-Type", "application/json")
-	if c.Namespace != nil {
-		namespaceHeader, err := json.Marshal(c.Namespace)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Dropbox-API-Path-Root", string(namespaceHeader))
+	auth := subdomain != "notify"
+	if err := c.setHeaders(ctx, path, req.Header, auth); err != nil {
+		return nil, err
 	}
 
-	r, _, err := c.do(req)
-	return r, err
+	call := &Call{Route: path, Host: subdomain, Arg: body, Request: req}
+	res, err := c.perform(call, auth)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
 }
 
 // download style endpoint.
@@ -70,68 +92,278 @@ func (c *Client) download(
 	path string,
 	in interface{},
 	r io.Reader,
-) (io.ReadCloser, int64, error) {
-	url := fmt.Sprintf("https://%s.dropboxapi.com/2/%s", subdomain, path)
+) (*http.Response, error) {
+	url := c.endpoints().host(subdomain) + "/2" + path
 
 	body, err := json.Marshal(in)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	req, err := http.NewRequest("POST", url, r)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
-	req.Header.Set("Dropbox-API-Arg", string(body))
-	if c.Namespace != nil {
-		namespaceHeader, err := json.Marshal(c.Namespace)
-		if err != nil {
-			return nil, 0, err
-		}
-		req.Header.Set("Dropbox-API-Path-Root", string(namespaceHeader))
+	req.Header.Set("Dropbox-API-Arg", headerArg(body))
+	if err := c.setHeaders(ctx, path, req.Header, true); err != nil {
+		return nil, err
+	}
+
+	if h, ok := in.(headerSetter); ok {
+		h.setHeaders(req.Header)
 	}
 
 	if r != nil {
 		req.Header.Set("Content-Type", "application/octet-stream")
+		replayable(req, r)
+	}
+
+	return c.perform(&Call{Route: path, Host: subdomain, Arg: body, Request: req}, true)
+}
+
+// headerArg escapes a JSON argument for the Dropbox-API-Arg header, which
+// must be ASCII. Code points of 0x7F and above are written as \uXXXX escapes,
+// using a UTF-16 surrogate pair beyond the Basic Multilingual Plane, as
+// Dropbox requires. Control characters are already escaped by json.Marshal.
+func headerArg(b []byte) string {
+	var buf strings.Builder
+	for len(b) > 0 {
+		if b[0] < utf8.RuneSelf-1 {
+			buf.WriteByte(b[0])
+			b = b[1:]
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			fmt.Fprintf(&buf, `\u%04x\u%04x`, r1, r2)
+		} else {
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		}
 	}
+	return buf.String()
+}
+
+// setHeaders sets the path root and team member selection headers of the
+// config on a request, overridden by the call options of ctx, then the extra
+// headers of the call options. Requests which are not authorized, such as a
+// longpoll, carry neither, and the team endpoints, which Dropbox rejects a
+// team member selection for, carry no selection.
+func (c *Client) setHeaders(ctx context.Context, route string, h http.Header, auth bool) error {
+	o := callOptionsFrom(ctx)
+
+	if auth {
+		namespace := c.Namespace
+		if o.namespace != nil {
+			namespace = o.namespace
+		}
+
+		if namespace != nil {
+			namespaceHeader, err := json.Marshal(namespace)
+			if err != nil {
+				return err
+			}
+			h.Set("Dropbox-API-Path-Root", string(namespaceHeader))
+		}
 
-	return c.do(req)
+		selectUser, selectAdmin := c.SelectUser, c.SelectAdmin
+		if o.selectUser != "" || o.selectAdmin != "" {
+			selectUser, selectAdmin = o.selectUser, o.selectAdmin
+		}
+
+		if !isTeamRoute(route) {
+			if selectUser != "" {
+				h.Set("Dropbox-API-Select-User", selectUser)
+			}
+			if selectAdmin != "" {
+				h.Set("Dropbox-API-Select-Admin", selectAdmin)
+			}
+		}
+	}
+
+	for k, v := range o.header {
+		h[k] = v
+	}
+	return nil
 }
 
-// perform the request.
-func (c *Client) do(req *http.Request) (io.ReadCloser, int64, error) {
+// isTeamRoute reports whether the endpoint acts on the team rather than on a
+// member's Dropbox.
+func isTeamRoute(route string) bool {
+	return strings.HasPrefix(route, "/team/") || strings.HasPrefix(route, "/team_log/")
+}
+
+// headerSetter is implemented by content-style inputs which pass options in
+// HTTP headers rather than the Dropbox-API-Arg, such as a download range.
+type headerSetter interface {
+	setHeaders(h http.Header)
+}
+
+// apiResult decodes the Dropbox-API-Result header of a content-download
+// response into v. The body is closed if the header cannot be decoded.
+func apiResult(res *http.Response, v interface{}) error {
+	result := res.Header.Get("Dropbox-API-Result")
+	if result == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(result), v); err != nil {
+		res.Body.Close()
+		return err
+	}
+	return nil
+}
+
+// replayable allows the request to be retried when its body is a reader which
+// can be rewound. Readers from the bytes and strings packages are already
+// handled by http.NewRequest.
+func replayable(req *http.Request, r io.Reader) {
+	s, ok := r.(io.Seeker)
+	if !ok || req.GetBody != nil {
+		return
+	}
+
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return
+	}
+
+	// The transport closes the body after each attempt, so it must not be
+	// allowed to close the caller's reader.
+	req.Body = ioutil.NopCloser(r)
+	req.GetBody = func() (io.ReadCloser, error) {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(r), nil
+	}
+}
+
+// perform the call with the timeout of its call options, if any, which is
+// released once the response body is closed.
+func (c *Client) perform(call *Call, auth bool) (*http.Response, error) {
+	ctx := call.Request.Context()
+	d := callOptionsFrom(ctx).timeout
+	if d <= 0 {
+		return c.do(call, auth)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	call.Request = call.Request.WithContext(ctx)
+	res, err := c.do(call, auth)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// do the call through the middleware, retrying according to the retry
+// policy and refreshing the access token once if it has expired.
+func (c *Client) do(call *Call, auth bool) (*http.Response, error) {
+	req := call.Request
+	ctx := req.Context()
+	refreshed := false
+	handler := c.handler()
+
+	for attempt := 1; ; attempt++ {
+		if auth {
+			token, err := c.token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		call.Attempt = attempt
+		call.Request = req
+		res, err := handler(call)
+		req = call.Request
+
+		e, ok := err.(*Error)
+		if !ok {
+			return res, err
+		}
+
+		var wait time.Duration
+		switch {
+		case !refreshed && e.StatusCode == http.StatusUnauthorized && hasTag(e, ExpiredAccessToken):
+			refreshed = true
+			ok, err := c.refresh(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, e
+			}
+			attempt--
+		case attempt < c.Retry.attempts() && c.Retry.retryable(e):
+			wait = c.Retry.backoff(attempt, e)
+		default:
+			return nil, err
+		}
+
+		if req.Body != nil && req.GetBody == nil {
+			return nil, &NotReplayableError{Err: e}
+		}
+
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		req = req.Clone(ctx)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// send the request once.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
 	res, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	if res.StatusCode < 400 {
-		return res.Body, res.ContentLength, err
+		return res, err
 	}
 
 	defer res.Body.Close()
 
 	e := &Error{
-		Status:     http.StatusText(res.StatusCode),
-		StatusCode: res.StatusCode,
-		Header:     res.Header,
+		Status:      http.StatusText(res.StatusCode),
+		StatusCode:  res.StatusCode,
+		Header:      res.Header,
+		RequestID:   res.Header.Get("X-Dropbox-Request-Id"),
+		ContentType: res.Header.Get("Content-Type"),
 	}
 
-	kind := res.Header.Get("Content-Type")
-
-	if !strings.Contains(kind, "json") {
-		if b, err := ioutil.ReadAll(res.Body); err == nil {
-			e.Summary = string(b)
-			return nil, 0, e
+	if strings.Contains(e.ContentType, "json") {
+		if err := json.NewDecoder(res.Body).Decode(e); err != nil {
+			return nil, err
 		}
-		return nil, 0, err
+	} else {
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		e.Summary = string(b)
 	}
 
-	if err := json.NewDecoder(res.Body).Decode(e); err != nil {
-		return nil, 0, err
-	}
+	e.RetryAfter, e.retryAfterSet = retryAfter(e)
+	e.Reason = rateLimitReason(e)
 
-	return nil, 0, e
+	return nil, e
 }
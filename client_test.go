@@ -2,21 +2,34 @@ package dropbox
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
-	env "github.com/segmentio/go-env"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var ctx = context.Background()
 
-func client() *Client {
-	token := env.MustGet("DROPBOX_ACCESS_TOKEN")
+// client for the live Dropbox API, skipping the test when no access token is
+// set so the offline tests still run.
+func client(t *testing.T) *Client {
+	t.Helper()
+
+	token := os.Getenv("DROPBOX_ACCESS_TOKEN")
+	if token == "" {
+		t.Skip("DROPBOX_ACCESS_TOKEN is not set")
+	}
 	return New(NewConfig(token))
 }
 
 func TestClient_error_text(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	_, err := c.Files.Download(ctx, &DownloadInput{
 		Path: "asdfasdfasdf",
@@ -31,9 +44,9 @@ func TestClient_error_text(t *testing.T) {
 }
 
 func TestClient_error_json(t *testing.T) {
-	c := client()
+	c := client(t)
 
-	_, err := c.Files.Download(ctx, &DownloadInput{"/nothing"})
+	_, err := c.Files.Download(ctx, &DownloadInput{Path: "/nothing"})
 	assert.Error(t, err)
 
 	e := err.(*Error)
@@ -41,3 +54,116 @@ func TestClient_error_json(t *testing.T) {
 	assert.Equal(t, "Conflict", e.Status)
 	assert.Equal(t, 409, e.StatusCode)
 }
+
+// testClient returns a client whose endpoints all point at a local server
+// backed by handler.
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	s := httptest.NewServer(handler)
+	t.Cleanup(s.Close)
+
+	config := NewConfig("token")
+	config.Endpoints = NewEndpoints(s.URL)
+	return New(config)
+}
+
+func TestClient_error_rateLimit(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Dropbox-Request-Id", "a1b2c3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error_summary": "too_many_write_operations/..", "error": {"reason": {".tag": "too_many_write_operations"}, "retry_after": 3}}`)
+	})
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	assert.Error(t, err)
+
+	e := err.(*Error)
+	assert.Equal(t, "a1b2c3", e.RequestID)
+	assert.Equal(t, "application/json", e.ContentType)
+	assert.Equal(t, 3*time.Second, e.RetryAfter)
+	assert.Equal(t, TooManyWriteOperations, e.Reason)
+}
+
+func TestClient_error_textMetadata(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Dropbox-Request-Id", "d4e5f6")
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "Service Unavailable")
+	})
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	assert.Error(t, err)
+
+	e := err.(*Error)
+	assert.Equal(t, "Service Unavailable", e.Summary)
+	assert.Equal(t, "d4e5f6", e.RequestID)
+	assert.Equal(t, "text/plain; charset=utf-8", e.ContentType)
+	assert.Equal(t, 12*time.Second, e.RetryAfter)
+	assert.Empty(t, e.Reason)
+}
+
+func TestClient_endpoints(t *testing.T) {
+	var paths []string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{".tag": "file"}`)
+	})
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	assert.NoError(t, err)
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt"})
+	assert.NoError(t, err)
+	out.Body.Close()
+
+	assert.Equal(t, []string{"/2/files/get_metadata", "/2/files/download"}, paths)
+}
+
+func TestHeaderArg(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"/hello.txt", `"/hello.txt"`},
+		{"/Résumé.pdf", `"/R\u00e9sum\u00e9.pdf"`},
+		{"/文档/报告.txt", `"/\u6587\u6863/\u62a5\u544a.txt"`},
+		{"/😀.png", `"/\ud83d\ude00.png"`},
+		{"/del\x7f", `"/del\u007f"`},
+		{"/tab\tnew\nline\x01", `"/tab\tnew\nline\u0001"`},
+		{"/a\u2028b", `"/a\u2028b"`},
+		{"/<&>", `"/\u003c\u0026\u003e"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in, func(t *testing.T) {
+			b, err := json.Marshal(c.in)
+			require.NoError(t, err)
+
+			arg := headerArg(b)
+			assert.Equal(t, c.want, arg)
+
+			var s string
+			require.NoError(t, json.Unmarshal([]byte(arg), &s))
+			assert.Equal(t, c.in, s)
+		})
+	}
+}
+
+func TestClient_download_nonASCII(t *testing.T) {
+	var arg string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		arg = r.Header.Get("Dropbox-API-Arg")
+		w.Header().Set("Dropbox-API-Result", `{"name": "Résumé 😀.pdf"}`)
+		io.WriteString(w, "hello")
+	})
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/Résumé 😀.pdf"})
+	require.NoError(t, err)
+	out.Body.Close()
+
+	assert.Equal(t, `{"path":"/R\u00e9sum\u00e9 \ud83d\ude00.pdf"}`, arg)
+}
@@ -1,14 +1,22 @@
 package dropbox
 
 import (
+	"context"
 	"net/http"
+	"strings"
 )
 
 // Config for the Dropbox clients.
 type Config struct {
 	HTTPClient  *http.Client
 	AccessToken string
+	TokenSource TokenSource // takes precedence over AccessToken when set
 	Namespace   *APIPathRoot
+	SelectUser  string       // team member ID to act as, with a team access token
+	SelectAdmin string       // team admin ID to act as, with a team access token
+	Endpoints   *Endpoints   // defaults to DefaultEndpoints when nil
+	Retry       *RetryPolicy // failed requests are not retried when nil
+	Middleware  []Middleware // wraps every call, the first outermost
 }
 
 // NewConfig with the given access token.
@@ -19,6 +27,91 @@ func NewConfig(accessToken string) *Config {
 	}
 }
 
+// NewTokenSourceConfig with the given token source, such as a
+// RefreshTokenSource for short-lived access tokens.
+func NewTokenSourceConfig(source TokenSource) *Config {
+	return &Config{
+		HTTPClient:  http.DefaultClient,
+		TokenSource: source,
+	}
+}
+
+// Endpoints holds the base URLs of the Dropbox API hosts, without the API
+// version. Override them to point the clients at a local stand-in server.
+type Endpoints struct {
+	API     string
+	Content string
+	Notify  string
+	Web     string // serves the OAuth2 authorization page
+}
+
+// DefaultEndpoints are the production Dropbox API hosts.
+var DefaultEndpoints = &Endpoints{
+	API:     "https://api.dropboxapi.com",
+	Content: "https://content.dropboxapi.com",
+	Notify:  "https://notify.dropboxapi.com",
+	Web:     "https://www.dropbox.com",
+}
+
+// NewEndpoints returns Endpoints which send every request to baseURL, which is
+// convenient for tests served by a single httptest.Server.
+func NewEndpoints(baseURL string) *Endpoints {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &Endpoints{
+		API:     baseURL,
+		Content: baseURL,
+		Notify:  baseURL,
+		Web:     baseURL,
+	}
+}
+
+// host returns the base URL for the given Dropbox API subdomain.
+func (e *Endpoints) host(subdomain string) string {
+	switch subdomain {
+	case "content":
+		return e.Content
+	case "notify":
+		return e.Notify
+	default:
+		return e.API
+	}
+}
+
+// token returns the access token for the next request.
+func (c *Config) token(ctx context.Context) (string, error) {
+	if c.TokenSource == nil {
+		return c.AccessToken, nil
+	}
+
+	token, err := c.TokenSource.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// refresh replaces an access token which Dropbox rejected as expired, and
+// reports whether the token source was able to do so.
+func (c *Config) refresh(ctx context.Context) (bool, error) {
+	refresher, ok := c.TokenSource.(TokenRefresher)
+	if !ok {
+		return false, nil
+	}
+
+	if _, err := refresher.Refresh(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// endpoints returns the configured endpoints or the defaults.
+func (c *Config) endpoints() *Endpoints {
+	if c.Endpoints != nil {
+		return c.Endpoints
+	}
+	return DefaultEndpoints
+}
+
 // APIPathRoot is marshalled onto the Dropbox-API-Path-Root header to
 // indicate which namespace to send Dropbox API requests relative to.
 // doc: https://www.dropbox.com/developers/reference/namespace-guide
@@ -0,0 +1,151 @@
+package dropbox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// ContentHashBlockSize is the size of the blocks hashed by ContentHash.
+const ContentHashBlockSize = 4 << 20
+
+// ContentHash computes the content_hash which Dropbox reports in file
+// Metadata: the SHA-256 of the concatenated SHA-256 digests of each 4MB block
+// of the file. It implements hash.Hash, and its hex encoded Sum matches
+// FileMetadata.ContentHash.
+// doc: https://www.dropbox.com/developers/reference/content-hash
+type ContentHash struct {
+	blocks []byte    // digests of the completed blocks
+	block  hash.Hash // digest of the current block
+	n      int       // bytes written to the current block
+}
+
+var _ hash.Hash = (*ContentHash)(nil)
+
+// NewContentHash returns an empty ContentHash.
+func NewContentHash() *ContentHash {
+	return &ContentHash{block: sha256.New()}
+}
+
+// Write adds more data to the hash. It never returns an error.
+func (h *ContentHash) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := ContentHashBlockSize - h.n
+		if n > len(p) {
+			n = len(p)
+		}
+
+		h.block.Write(p[:n])
+		h.n += n
+		p = p[n:]
+
+		if h.n == ContentHashBlockSize {
+			h.blocks = h.block.Sum(h.blocks)
+			h.block.Reset()
+			h.n = 0
+		}
+	}
+	return written, nil
+}
+
+// Sum appends the hash to b without changing the underlying state.
+func (h *ContentHash) Sum(b []byte) []byte {
+	blocks := h.blocks
+	if h.n > 0 {
+		blocks = h.block.Sum(append([]byte(nil), blocks...))
+	}
+
+	sum := sha256.Sum256(blocks)
+	return append(b, sum[:]...)
+}
+
+// Reset the hash to its initial state.
+func (h *ContentHash) Reset() {
+	h.blocks = h.blocks[:0]
+	h.block.Reset()
+	h.n = 0
+}
+
+// Size returns the number of bytes Sum returns.
+func (h *ContentHash) Size() int {
+	return sha256.Size
+}
+
+// BlockSize returns the hash's underlying block size.
+func (h *ContentHash) BlockSize() int {
+	return sha256.BlockSize
+}
+
+// String returns the hex encoded hash, as used by FileMetadata.ContentHash.
+func (h *ContentHash) String() string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ContentHashReader returns the hex encoded content hash of everything read
+// from r.
+func ContentHashReader(r io.Reader) (string, error) {
+	h := NewContentHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
+
+// ContentHashFile returns the hex encoded content hash of the local file.
+func ContentHashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ContentHashReader(f)
+}
+
+// IntegrityError is returned when the content hash of uploaded or downloaded
+// data does not match the content hash Dropbox reports for the file.
+type IntegrityError struct {
+	Path     string
+	Expected string // content hash reported by Dropbox
+	Actual   string // content hash of the data sent or received
+}
+
+// Error string.
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("dropbox: content hash mismatch for %s: expected %s, got %s", e.Path, e.Expected, e.Actual)
+}
+
+// checkContentHash returns an *IntegrityError unless h matches expected.
+func checkContentHash(path string, h *ContentHash, expected string) error {
+	if actual := h.String(); actual != expected {
+		return &IntegrityError{Path: path, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// verifyingReader checks the content hash of a download once it has been
+// read to the end.
+type verifyingReader struct {
+	io.ReadCloser
+	hash     *ContentHash
+	path     string
+	expected string
+}
+
+// Read returns an *IntegrityError in place of io.EOF if the content hash does
+// not match.
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+
+	if err == io.EOF {
+		if err := checkContentHash(r.path, r.hash, r.expected); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,129 @@
+package dropbox
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceContentHash computes the content hash one block at a time.
+func referenceContentHash(data []byte) string {
+	var blocks []byte
+	for len(data) > 0 {
+		n := ContentHashBlockSize
+		if n > len(data) {
+			n = len(data)
+		}
+		sum := sha256.Sum256(data[:n])
+		blocks = append(blocks, sum[:]...)
+		data = data[n:]
+	}
+	sum := sha256.Sum256(blocks)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestContentHash(t *testing.T) {
+	sizes := []int{0, 1, ContentHashBlockSize - 1, ContentHashBlockSize, 2*ContentHashBlockSize + 7}
+	for _, size := range sizes {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			data := bytes.Repeat([]byte{0x5a}, size)
+			h := NewContentHash()
+
+			// Write in uneven pieces to cross block boundaries mid-write.
+			for p := data; len(p) > 0; {
+				n := 1<<20 + 3
+				if n > len(p) {
+					n = len(p)
+				}
+				h.Write(p[:n])
+				p = p[n:]
+			}
+
+			assert.Equal(t, referenceContentHash(data), h.String())
+			assert.Equal(t, h.String(), h.String(), "Sum should not change the state")
+		})
+	}
+
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", NewContentHash().String())
+}
+
+func TestContentHash_Reset(t *testing.T) {
+	h := NewContentHash()
+	h.Write(bytes.Repeat([]byte{1}, ContentHashBlockSize+1))
+	h.Reset()
+	h.Write([]byte("hello"))
+	assert.Equal(t, referenceContentHash([]byte("hello")), h.String())
+}
+
+func TestContentHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0600))
+
+	sum, err := ContentHashFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, referenceContentHash([]byte("hello")), sum)
+}
+
+func TestFiles_Upload_verify(t *testing.T) {
+	var arg map[string]interface{}
+	reported := referenceContentHash([]byte("hello"))
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		arg = nil
+		require.NoError(t, json.Unmarshal([]byte(r.Header.Get("Dropbox-API-Arg")), &arg))
+		ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name": "hello.txt", "content_hash": %q}`, reported)
+	})
+
+	out, err := c.Files.Upload(ctx, &UploadInput{
+		Path:              "/hello.txt",
+		Reader:            strings.NewReader("hello"),
+		VerifyContentHash: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, reported, out.ContentHash)
+	assert.Equal(t, reported, arg["content_hash"], "seekable readers should send the hash to Dropbox")
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("HELLO"))
+		pw.Close()
+	}()
+
+	_, err = c.Files.Upload(ctx, &UploadInput{
+		Path:              "/hello.txt",
+		Reader:            pr,
+		VerifyContentHash: true,
+	})
+	require.IsType(t, &IntegrityError{}, err)
+	assert.Equal(t, reported, err.(*IntegrityError).Expected)
+	assert.Equal(t, referenceContentHash([]byte("HELLO")), err.(*IntegrityError).Actual)
+	assert.NotContains(t, arg, "content_hash")
+}
+
+func TestFiles_Download_verify(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Dropbox-API-Result", fmt.Sprintf(`{"name": "hello.txt", "content_hash": %q}`, referenceContentHash([]byte("hello"))))
+		io.WriteString(w, "jello")
+	})
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt", VerifyContentHash: true})
+	require.NoError(t, err)
+	defer out.Body.Close()
+
+	b, err := ioutil.ReadAll(out.Body)
+	assert.Equal(t, "jello", string(b))
+	require.IsType(t, &IntegrityError{}, err)
+	assert.Equal(t, "/hello.txt", err.(*IntegrityError).Path)
+}
@@ -0,0 +1,293 @@
+package dropboxmock
+
+import (
+	"context"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// Files is a mock dropbox.FilesAPI. Each method records its call and calls
+// the function field of the same name, returning ErrNotMocked when it is
+// nil. Iterator methods without a function page through the list methods.
+type Files struct {
+	Recorder
+
+	GetMetadataFunc                   func(ctx context.Context, in *dropbox.GetMetadataInput) (*dropbox.GetMetadataOutput, error)
+	CreateFolderFunc                  func(ctx context.Context, in *dropbox.CreateFolderInput) (*dropbox.CreateFolderOutput, error)
+	DeleteFunc                        func(ctx context.Context, in *dropbox.DeleteInput) (*dropbox.DeleteOutput, error)
+	PermanentlyDeleteFunc             func(ctx context.Context, in *dropbox.PermanentlyDeleteInput) error
+	CopyFunc                          func(ctx context.Context, in *dropbox.CopyInput) (*dropbox.CopyOutput, error)
+	MoveFunc                          func(ctx context.Context, in *dropbox.MoveInput) (*dropbox.MoveOutput, error)
+	RestoreFunc                       func(ctx context.Context, in *dropbox.RestoreInput) (*dropbox.RestoreOutput, error)
+	ListFolderFunc                    func(ctx context.Context, in *dropbox.ListFolderInput) (*dropbox.ListFolderOutput, error)
+	ListFolderContinueFunc            func(ctx context.Context, in *dropbox.ListFolderContinueInput) (*dropbox.ListFolderOutput, error)
+	ListFolderIteratorFunc            func(in *dropbox.ListFolderInput) *dropbox.Iterator[dropbox.Metadata]
+	GetLatestCursorFunc               func(ctx context.Context, in *dropbox.ListFolderInput) (*dropbox.GetLatestCursorOutput, error)
+	ListFolderLongpollFunc            func(ctx context.Context, in *dropbox.ListFolderLongpollInput) (*dropbox.ListFolderLongpollOutput, error)
+	SearchFunc                        func(ctx context.Context, in *dropbox.SearchInput) (*dropbox.SearchOutput, error)
+	UploadFunc                        func(ctx context.Context, in *dropbox.UploadInput) (*dropbox.UploadOutput, error)
+	UploadSessionStartFunc            func(ctx context.Context, in *dropbox.UploadSessionStartInput) (*dropbox.UploadSessionStartOutput, error)
+	UploadSessionAppendFunc           func(ctx context.Context, in *dropbox.UploadSessionAppendInput) error
+	UploadSessionFinishFunc           func(ctx context.Context, in *dropbox.UploadSessionFinishInput) (*dropbox.UploadOutput, error)
+	UploadSessionFinishBatchFunc      func(ctx context.Context, in *dropbox.UploadSessionFinishBatchInput) (*dropbox.UploadSessionFinishBatchOutput, error)
+	UploadSessionFinishBatchCheckFunc func(ctx context.Context, in *dropbox.AsyncJobInput) (*dropbox.UploadSessionFinishBatchOutput, error)
+	UploadLargeFunc                   func(ctx context.Context, in *dropbox.UploadLargeInput) (*dropbox.UploadOutput, error)
+	UploadBatchFunc                   func(ctx context.Context, in *dropbox.UploadBatchInput) (*dropbox.UploadBatchOutput, error)
+	DownloadFunc                      func(ctx context.Context, in *dropbox.DownloadInput) (*dropbox.DownloadOutput, error)
+	ResumeDownloadFunc                func(ctx context.Context, in *dropbox.ResumeDownloadInput) (*dropbox.ResumeDownloadOutput, error)
+	GetThumbnailFunc                  func(ctx context.Context, in *dropbox.GetThumbnailInput) (*dropbox.GetThumbnailOutput, error)
+	GetPreviewFunc                    func(ctx context.Context, in *dropbox.GetPreviewInput) (*dropbox.GetPreviewOutput, error)
+	ListRevisionsFunc                 func(ctx context.Context, in *dropbox.ListRevisionsInput) (*dropbox.ListRevisionsOutput, error)
+}
+
+var _ dropbox.FilesAPI = (*Files)(nil)
+
+// GetMetadata calls GetMetadataFunc.
+func (m *Files) GetMetadata(ctx context.Context, in *dropbox.GetMetadataInput) (*dropbox.GetMetadataOutput, error) {
+	m.record("GetMetadata", in)
+	if m.GetMetadataFunc == nil {
+		return nil, notMocked("Files.GetMetadata")
+	}
+	return m.GetMetadataFunc(ctx, in)
+}
+
+// CreateFolder calls CreateFolderFunc.
+func (m *Files) CreateFolder(ctx context.Context, in *dropbox.CreateFolderInput) (*dropbox.CreateFolderOutput, error) {
+	m.record("CreateFolder", in)
+	if m.CreateFolderFunc == nil {
+		return nil, notMocked("Files.CreateFolder")
+	}
+	return m.CreateFolderFunc(ctx, in)
+}
+
+// Delete calls DeleteFunc.
+func (m *Files) Delete(ctx context.Context, in *dropbox.DeleteInput) (*dropbox.DeleteOutput, error) {
+	m.record("Delete", in)
+	if m.DeleteFunc == nil {
+		return nil, notMocked("Files.Delete")
+	}
+	return m.DeleteFunc(ctx, in)
+}
+
+// PermanentlyDelete calls PermanentlyDeleteFunc.
+func (m *Files) PermanentlyDelete(ctx context.Context, in *dropbox.PermanentlyDeleteInput) error {
+	m.record("PermanentlyDelete", in)
+	if m.PermanentlyDeleteFunc == nil {
+		return notMocked("Files.PermanentlyDelete")
+	}
+	return m.PermanentlyDeleteFunc(ctx, in)
+}
+
+// Copy calls CopyFunc.
+func (m *Files) Copy(ctx context.Context, in *dropbox.CopyInput) (*dropbox.CopyOutput, error) {
+	m.record("Copy", in)
+	if m.CopyFunc == nil {
+		return nil, notMocked("Files.Copy")
+	}
+	return m.CopyFunc(ctx, in)
+}
+
+// Move calls MoveFunc.
+func (m *Files) Move(ctx context.Context, in *dropbox.MoveInput) (*dropbox.MoveOutput, error) {
+	m.record("Move", in)
+	if m.MoveFunc == nil {
+		return nil, notMocked("Files.Move")
+	}
+	return m.MoveFunc(ctx, in)
+}
+
+// Restore calls RestoreFunc.
+func (m *Files) Restore(ctx context.Context, in *dropbox.RestoreInput) (*dropbox.RestoreOutput, error) {
+	m.record("Restore", in)
+	if m.RestoreFunc == nil {
+		return nil, notMocked("Files.Restore")
+	}
+	return m.RestoreFunc(ctx, in)
+}
+
+// ListFolder calls ListFolderFunc.
+func (m *Files) ListFolder(ctx context.Context, in *dropbox.ListFolderInput) (*dropbox.ListFolderOutput, error) {
+	m.record("ListFolder", in)
+	if m.ListFolderFunc == nil {
+		return nil, notMocked("Files.ListFolder")
+	}
+	return m.ListFolderFunc(ctx, in)
+}
+
+// ListFolderContinue calls ListFolderContinueFunc.
+func (m *Files) ListFolderContinue(ctx context.Context, in *dropbox.ListFolderContinueInput) (*dropbox.ListFolderOutput, error) {
+	m.record("ListFolderContinue", in)
+	if m.ListFolderContinueFunc == nil {
+		return nil, notMocked("Files.ListFolderContinue")
+	}
+	return m.ListFolderContinueFunc(ctx, in)
+}
+
+// ListFolderIterator calls ListFolderIteratorFunc, or returns an iterator
+// over ListFolder and ListFolderContinue.
+func (m *Files) ListFolderIterator(in *dropbox.ListFolderInput) *dropbox.Iterator[dropbox.Metadata] {
+	m.record("ListFolderIterator", in)
+	if m.ListFolderIteratorFunc != nil {
+		return m.ListFolderIteratorFunc(in)
+	}
+	return dropbox.NewIterator(
+		func(ctx context.Context) ([]dropbox.Metadata, string, bool, error) {
+			out, err := m.ListFolder(ctx, in)
+			if err != nil {
+				return nil, "", false, err
+			}
+			return out.Entries, out.Cursor, out.HasMore, nil
+		},
+		func(ctx context.Context, cursor string) ([]dropbox.Metadata, string, bool, error) {
+			out, err := m.ListFolderContinue(ctx, &dropbox.ListFolderContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, "", false, err
+			}
+			return out.Entries, out.Cursor, out.HasMore, nil
+		},
+	)
+}
+
+// GetLatestCursor calls GetLatestCursorFunc.
+func (m *Files) GetLatestCursor(ctx context.Context, in *dropbox.ListFolderInput) (*dropbox.GetLatestCursorOutput, error) {
+	m.record("GetLatestCursor", in)
+	if m.GetLatestCursorFunc == nil {
+		return nil, notMocked("Files.GetLatestCursor")
+	}
+	return m.GetLatestCursorFunc(ctx, in)
+}
+
+// ListFolderLongpoll calls ListFolderLongpollFunc.
+func (m *Files) ListFolderLongpoll(ctx context.Context, in *dropbox.ListFolderLongpollInput) (*dropbox.ListFolderLongpollOutput, error) {
+	m.record("ListFolderLongpoll", in)
+	if m.ListFolderLongpollFunc == nil {
+		return nil, notMocked("Files.ListFolderLongpoll")
+	}
+	return m.ListFolderLongpollFunc(ctx, in)
+}
+
+// Search calls SearchFunc.
+func (m *Files) Search(ctx context.Context, in *dropbox.SearchInput) (*dropbox.SearchOutput, error) {
+	m.record("Search", in)
+	if m.SearchFunc == nil {
+		return nil, notMocked("Files.Search")
+	}
+	return m.SearchFunc(ctx, in)
+}
+
+// Upload calls UploadFunc.
+func (m *Files) Upload(ctx context.Context, in *dropbox.UploadInput) (*dropbox.UploadOutput, error) {
+	m.record("Upload", in)
+	if m.UploadFunc == nil {
+		return nil, notMocked("Files.Upload")
+	}
+	return m.UploadFunc(ctx, in)
+}
+
+// UploadSessionStart calls UploadSessionStartFunc.
+func (m *Files) UploadSessionStart(ctx context.Context, in *dropbox.UploadSessionStartInput) (*dropbox.UploadSessionStartOutput, error) {
+	m.record("UploadSessionStart", in)
+	if m.UploadSessionStartFunc == nil {
+		return nil, notMocked("Files.UploadSessionStart")
+	}
+	return m.UploadSessionStartFunc(ctx, in)
+}
+
+// UploadSessionAppend calls UploadSessionAppendFunc.
+func (m *Files) UploadSessionAppend(ctx context.Context, in *dropbox.UploadSessionAppendInput) error {
+	m.record("UploadSessionAppend", in)
+	if m.UploadSessionAppendFunc == nil {
+		return notMocked("Files.UploadSessionAppend")
+	}
+	return m.UploadSessionAppendFunc(ctx, in)
+}
+
+// UploadSessionFinish calls UploadSessionFinishFunc.
+func (m *Files) UploadSessionFinish(ctx context.Context, in *dropbox.UploadSessionFinishInput) (*dropbox.UploadOutput, error) {
+	m.record("UploadSessionFinish", in)
+	if m.UploadSessionFinishFunc == nil {
+		return nil, notMocked("Files.UploadSessionFinish")
+	}
+	return m.UploadSessionFinishFunc(ctx, in)
+}
+
+// UploadSessionFinishBatch calls UploadSessionFinishBatchFunc.
+func (m *Files) UploadSessionFinishBatch(ctx context.Context, in *dropbox.UploadSessionFinishBatchInput) (*dropbox.UploadSessionFinishBatchOutput, error) {
+	m.record("UploadSessionFinishBatch", in)
+	if m.UploadSessionFinishBatchFunc == nil {
+		return nil, notMocked("Files.UploadSessionFinishBatch")
+	}
+	return m.UploadSessionFinishBatchFunc(ctx, in)
+}
+
+// UploadSessionFinishBatchCheck calls UploadSessionFinishBatchCheckFunc.
+func (m *Files) UploadSessionFinishBatchCheck(ctx context.Context, in *dropbox.AsyncJobInput) (*dropbox.UploadSessionFinishBatchOutput, error) {
+	m.record("UploadSessionFinishBatchCheck", in)
+	if m.UploadSessionFinishBatchCheckFunc == nil {
+		return nil, notMocked("Files.UploadSessionFinishBatchCheck")
+	}
+	return m.UploadSessionFinishBatchCheckFunc(ctx, in)
+}
+
+// UploadLarge calls UploadLargeFunc.
+func (m *Files) UploadLarge(ctx context.Context, in *dropbox.UploadLargeInput) (*dropbox.UploadOutput, error) {
+	m.record("UploadLarge", in)
+	if m.UploadLargeFunc == nil {
+		return nil, notMocked("Files.UploadLarge")
+	}
+	return m.UploadLargeFunc(ctx, in)
+}
+
+// UploadBatch calls UploadBatchFunc.
+func (m *Files) UploadBatch(ctx context.Context, in *dropbox.UploadBatchInput) (*dropbox.UploadBatchOutput, error) {
+	m.record("UploadBatch", in)
+	if m.UploadBatchFunc == nil {
+		return nil, notMocked("Files.UploadBatch")
+	}
+	return m.UploadBatchFunc(ctx, in)
+}
+
+// Download calls DownloadFunc.
+func (m *Files) Download(ctx context.Context, in *dropbox.DownloadInput) (*dropbox.DownloadOutput, error) {
+	m.record("Download", in)
+	if m.DownloadFunc == nil {
+		return nil, notMocked("Files.Download")
+	}
+	return m.DownloadFunc(ctx, in)
+}
+
+// ResumeDownload calls ResumeDownloadFunc.
+func (m *Files) ResumeDownload(ctx context.Context, in *dropbox.ResumeDownloadInput) (*dropbox.ResumeDownloadOutput, error) {
+	m.record("ResumeDownload", in)
+	if m.ResumeDownloadFunc == nil {
+		return nil, notMocked("Files.ResumeDownload")
+	}
+	return m.ResumeDownloadFunc(ctx, in)
+}
+
+// GetThumbnail calls GetThumbnailFunc.
+func (m *Files) GetThumbnail(ctx context.Context, in *dropbox.GetThumbnailInput) (*dropbox.GetThumbnailOutput, error) {
+	m.record("GetThumbnail", in)
+	if m.GetThumbnailFunc == nil {
+		return nil, notMocked("Files.GetThumbnail")
+	}
+	return m.GetThumbnailFunc(ctx, in)
+}
+
+// GetPreview calls GetPreviewFunc.
+func (m *Files) GetPreview(ctx context.Context, in *dropbox.GetPreviewInput) (*dropbox.GetPreviewOutput, error) {
+	m.record("GetPreview", in)
+	if m.GetPreviewFunc == nil {
+		return nil, notMocked("Files.GetPreview")
+	}
+	return m.GetPreviewFunc(ctx, in)
+}
+
+// ListRevisions calls ListRevisionsFunc.
+func (m *Files) ListRevisions(ctx context.Context, in *dropbox.ListRevisionsInput) (*dropbox.ListRevisionsOutput, error) {
+	m.record("ListRevisions", in)
+	if m.ListRevisionsFunc == nil {
+		return nil, notMocked("Files.ListRevisions")
+	}
+	return m.ListRevisionsFunc(ctx, in)
+}
@@ -0,0 +1,71 @@
+// Package dropboxmock provides mocks of the dropbox.FilesAPI, SharingAPI,
+// UsersAPI and PaperAPI interfaces which record their calls, for unit testing
+// code built on the dropbox package. To test against the HTTP API use the
+// dropboxtest package instead.
+//
+//	files := &dropboxmock.Files{
+//		GetMetadataFunc: func(ctx context.Context, in *dropbox.GetMetadataInput) (*dropbox.GetMetadataOutput, error) {
+//			return nil, &dropbox.Error{StatusCode: 409, Summary: "path/not_found/.."}
+//		},
+//	}
+//	client := &dropbox.Client{Files: files}
+//	...
+//	calls := files.Calls()
+package dropboxmock
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotMocked is returned by a mock method whose function is not set.
+var ErrNotMocked = errors.New("dropboxmock: method not mocked")
+
+// notMocked returns ErrNotMocked for the method.
+func notMocked(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotMocked, method)
+}
+
+// Call is a recorded call of a mock method.
+type Call struct {
+	Method string      // method name, such as "GetMetadata"
+	Input  interface{} // input argument, nil for methods without one
+}
+
+// Recorder records the calls made to a mock. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// record a call.
+func (r *Recorder) record(method string, in interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Input: in})
+}
+
+// Calls returns the calls made so far, in order.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Inputs returns the inputs of the calls made to method, in order.
+func (r *Recorder) Inputs(method string) (inputs []interface{}) {
+	for _, c := range r.Calls() {
+		if c.Method == method {
+			inputs = append(inputs, c.Input)
+		}
+	}
+	return
+}
+
+// Reset forgets the calls made so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
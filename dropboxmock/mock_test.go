@@ -0,0 +1,95 @@
+package dropboxmock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+var ctx = context.Background()
+
+func TestFiles(t *testing.T) {
+	files := &Files{
+		GetMetadataFunc: func(ctx context.Context, in *dropbox.GetMetadataInput) (*dropbox.GetMetadataOutput, error) {
+			return &dropbox.GetMetadataOutput{Metadata: &dropbox.FolderMetadata{}}, nil
+		},
+	}
+	client := &dropbox.Client{Files: files}
+
+	out, err := client.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/photos"})
+	require.NoError(t, err)
+	assert.Equal(t, "folder", out.Tag())
+
+	_, err = client.Files.Delete(ctx, &dropbox.DeleteInput{Path: "/photos"})
+	assert.True(t, errors.Is(err, ErrNotMocked))
+	assert.Contains(t, err.Error(), "Files.Delete")
+
+	assert.Equal(t, []Call{
+		{Method: "GetMetadata", Input: &dropbox.GetMetadataInput{Path: "/photos"}},
+		{Method: "Delete", Input: &dropbox.DeleteInput{Path: "/photos"}},
+	}, files.Calls())
+	assert.Len(t, files.Inputs("Delete"), 1)
+
+	files.Reset()
+	assert.Empty(t, files.Calls())
+}
+
+func TestFiles_ListFolderIterator(t *testing.T) {
+	files := &Files{
+		ListFolderFunc: func(ctx context.Context, in *dropbox.ListFolderInput) (*dropbox.ListFolderOutput, error) {
+			return &dropbox.ListFolderOutput{
+				Entries: []dropbox.Metadata{&dropbox.FileMetadata{EntryMetadata: dropbox.EntryMetadata{Name: "a.txt"}}},
+				Cursor:  "c1",
+				HasMore: true,
+			}, nil
+		},
+		ListFolderContinueFunc: func(ctx context.Context, in *dropbox.ListFolderContinueInput) (*dropbox.ListFolderOutput, error) {
+			assert.Equal(t, "c1", in.Cursor)
+			return &dropbox.ListFolderOutput{
+				Entries: []dropbox.Metadata{&dropbox.FileMetadata{EntryMetadata: dropbox.EntryMetadata{Name: "b.txt"}}},
+				Cursor:  "c2",
+			}, nil
+		},
+	}
+
+	it := files.ListFolderIterator(&dropbox.ListFolderInput{Path: "/"})
+	var names []string
+	for it.Next(ctx) {
+		names = append(names, it.Value().Entry().Name)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"a.txt", "b.txt"}, names)
+	assert.Len(t, files.Inputs("ListFolderContinue"), 1)
+}
+
+func TestSharing_ListSharedFolderMembersIterator(t *testing.T) {
+	var out dropbox.ListSharedMembersOutput
+	out.Users = make([]dropbox.UserMembershipInfo, 1)
+	out.Invitees = make([]dropbox.InviteeMembershipInfo, 1)
+
+	sharing := &Sharing{
+		ListSharedFolderMembersFunc: func(ctx context.Context, in *dropbox.ListSharedFolderMembersInput) (*dropbox.ListSharedMembersOutput, error) {
+			return &out, nil
+		},
+	}
+
+	it := sharing.ListSharedFolderMembersIterator(&dropbox.ListSharedFolderMembersInput{SharedFolderID: "1"})
+	require.True(t, it.Next(ctx))
+	assert.NotNil(t, it.Value().User)
+	require.True(t, it.Next(ctx))
+	assert.NotNil(t, it.Value().Invitee)
+	assert.False(t, it.Next(ctx))
+	assert.NoError(t, it.Err())
+}
+
+func TestUsers(t *testing.T) {
+	users := &Users{}
+	_, err := users.GetCurrentAccount(ctx)
+	assert.True(t, errors.Is(err, ErrNotMocked))
+	assert.Equal(t, []Call{{Method: "GetCurrentAccount"}}, users.Calls())
+}
@@ -0,0 +1,113 @@
+package dropboxmock
+
+import (
+	"context"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// Paper is a mock dropbox.PaperAPI. Each method records its call and calls
+// the function field of the same name, returning ErrNotMocked when it is
+// nil. Iterator methods without a function page through the list methods.
+type Paper struct {
+	Recorder
+
+	ListDocsFunc          func(ctx context.Context, in *dropbox.PaperDocsListInput) (*dropbox.PaperDocsListOutput, error)
+	ListDocsContinueFunc  func(ctx context.Context, in *dropbox.PaperDocsListContinueInput) (*dropbox.PaperDocsListOutput, error)
+	ListDocsIteratorFunc  func(in *dropbox.PaperDocsListInput) *dropbox.Iterator[string]
+	DownloadFunc          func(ctx context.Context, in *dropbox.PaperDownloadInput) (*dropbox.PaperDownloadOutput, error)
+	GetFolderInfoFunc     func(ctx context.Context, in *dropbox.PaperGetFolderInfoInput) (*dropbox.PaperGetFolderInfoOutput, error)
+	CreateFunc            func(ctx context.Context, in *dropbox.PaperCreateInput) (*dropbox.PaperCreateOutput, error)
+	PermanentlyDeleteFunc func(ctx context.Context, in *dropbox.PaperPermanentlyDeleteInput) error
+	AlphaGetMetadataFunc  func(ctx context.Context, in *dropbox.PaperGetMetadataInput) (*dropbox.PaperGetMetadataOutput, error)
+}
+
+var _ dropbox.PaperAPI = (*Paper)(nil)
+
+// ListDocs calls ListDocsFunc.
+func (m *Paper) ListDocs(ctx context.Context, in *dropbox.PaperDocsListInput) (*dropbox.PaperDocsListOutput, error) {
+	m.record("ListDocs", in)
+	if m.ListDocsFunc == nil {
+		return nil, notMocked("Paper.ListDocs")
+	}
+	return m.ListDocsFunc(ctx, in)
+}
+
+// ListDocsContinue calls ListDocsContinueFunc.
+func (m *Paper) ListDocsContinue(ctx context.Context, in *dropbox.PaperDocsListContinueInput) (*dropbox.PaperDocsListOutput, error) {
+	m.record("ListDocsContinue", in)
+	if m.ListDocsContinueFunc == nil {
+		return nil, notMocked("Paper.ListDocsContinue")
+	}
+	return m.ListDocsContinueFunc(ctx, in)
+}
+
+// ListDocsIterator calls ListDocsIteratorFunc, or returns an iterator over
+// ListDocs and ListDocsContinue.
+func (m *Paper) ListDocsIterator(in *dropbox.PaperDocsListInput) *dropbox.Iterator[string] {
+	m.record("ListDocsIterator", in)
+	if m.ListDocsIteratorFunc != nil {
+		return m.ListDocsIteratorFunc(in)
+	}
+	return dropbox.NewIterator(
+		func(ctx context.Context) ([]string, string, bool, error) {
+			out, err := m.ListDocs(ctx, in)
+			if err != nil {
+				return nil, "", false, err
+			}
+			return out.DocIDs, out.Cursor.Value, out.HasMore, nil
+		},
+		func(ctx context.Context, cursor string) ([]string, string, bool, error) {
+			out, err := m.ListDocsContinue(ctx, &dropbox.PaperDocsListContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, "", false, err
+			}
+			return out.DocIDs, out.Cursor.Value, out.HasMore, nil
+		},
+	)
+}
+
+// Download calls DownloadFunc.
+func (m *Paper) Download(ctx context.Context, in *dropbox.PaperDownloadInput) (*dropbox.PaperDownloadOutput, error) {
+	m.record("Download", in)
+	if m.DownloadFunc == nil {
+		return nil, notMocked("Paper.Download")
+	}
+	return m.DownloadFunc(ctx, in)
+}
+
+// GetFolderInfo calls GetFolderInfoFunc.
+func (m *Paper) GetFolderInfo(ctx context.Context, in *dropbox.PaperGetFolderInfoInput) (*dropbox.PaperGetFolderInfoOutput, error) {
+	m.record("GetFolderInfo", in)
+	if m.GetFolderInfoFunc == nil {
+		return nil, notMocked("Paper.GetFolderInfo")
+	}
+	return m.GetFolderInfoFunc(ctx, in)
+}
+
+// Create calls CreateFunc.
+func (m *Paper) Create(ctx context.Context, in *dropbox.PaperCreateInput) (*dropbox.PaperCreateOutput, error) {
+	m.record("Create", in)
+	if m.CreateFunc == nil {
+		return nil, notMocked("Paper.Create")
+	}
+	return m.CreateFunc(ctx, in)
+}
+
+// PermanentlyDelete calls PermanentlyDeleteFunc.
+func (m *Paper) PermanentlyDelete(ctx context.Context, in *dropbox.PaperPermanentlyDeleteInput) error {
+	m.record("PermanentlyDelete", in)
+	if m.PermanentlyDeleteFunc == nil {
+		return notMocked("Paper.PermanentlyDelete")
+	}
+	return m.PermanentlyDeleteFunc(ctx, in)
+}
+
+// AlphaGetMetadata calls AlphaGetMetadataFunc.
+func (m *Paper) AlphaGetMetadata(ctx context.Context, in *dropbox.PaperGetMetadataInput) (*dropbox.PaperGetMetadataOutput, error) {
+	m.record("AlphaGetMetadata", in)
+	if m.AlphaGetMetadataFunc == nil {
+		return nil, notMocked("Paper.AlphaGetMetadata")
+	}
+	return m.AlphaGetMetadataFunc(ctx, in)
+}
@@ -0,0 +1,182 @@
+package dropboxmock
+
+import (
+	"context"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// Sharing is a mock dropbox.SharingAPI. Each method records its call and calls
+// the function field of the same name, returning ErrNotMocked when it is
+// nil. Iterator methods without a function page through the list methods.
+type Sharing struct {
+	Recorder
+
+	CreateSharedLinkFunc                func(ctx context.Context, in *dropbox.CreateSharedLinkInput) (*dropbox.CreateSharedLinkOutput, error)
+	ListSharedFileMembersFunc           func(ctx context.Context, in *dropbox.ListSharedFileMembersInput) (*dropbox.ListSharedMembersOutput, error)
+	ListSharedFileMembersContinueFunc   func(ctx context.Context, in *dropbox.ListSharedMembersContinueInput) (*dropbox.ListSharedMembersOutput, error)
+	ListSharedFileMembersIteratorFunc   func(in *dropbox.ListSharedFileMembersInput) *dropbox.Iterator[dropbox.SharedMember]
+	ListSharedFolderMembersFunc         func(ctx context.Context, in *dropbox.ListSharedFolderMembersInput) (*dropbox.ListSharedMembersOutput, error)
+	ListSharedFolderMembersContinueFunc func(ctx context.Context, in *dropbox.ListSharedMembersContinueInput) (*dropbox.ListSharedMembersOutput, error)
+	ListSharedFolderMembersIteratorFunc func(in *dropbox.ListSharedFolderMembersInput) *dropbox.Iterator[dropbox.SharedMember]
+	ListSharedFoldersFunc               func(ctx context.Context, in *dropbox.ListSharedFolderInput) (*dropbox.ListSharedFolderOutput, error)
+	ListSharedFoldersContinueFunc       func(ctx context.Context, in *dropbox.ListSharedFolderContinueInput) (*dropbox.ListSharedFolderOutput, error)
+	ListSharedFoldersIteratorFunc       func(in *dropbox.ListSharedFolderInput) *dropbox.Iterator[dropbox.SharedFolderMetadata]
+}
+
+var _ dropbox.SharingAPI = (*Sharing)(nil)
+
+// CreateSharedLink calls CreateSharedLinkFunc.
+func (m *Sharing) CreateSharedLink(ctx context.Context, in *dropbox.CreateSharedLinkInput) (*dropbox.CreateSharedLinkOutput, error) {
+	m.record("CreateSharedLink", in)
+	if m.CreateSharedLinkFunc == nil {
+		return nil, notMocked("Sharing.CreateSharedLink")
+	}
+	return m.CreateSharedLinkFunc(ctx, in)
+}
+
+// ListSharedFileMembers calls ListSharedFileMembersFunc.
+func (m *Sharing) ListSharedFileMembers(ctx context.Context, in *dropbox.ListSharedFileMembersInput) (*dropbox.ListSharedMembersOutput, error) {
+	m.record("ListSharedFileMembers", in)
+	if m.ListSharedFileMembersFunc == nil {
+		return nil, notMocked("Sharing.ListSharedFileMembers")
+	}
+	return m.ListSharedFileMembersFunc(ctx, in)
+}
+
+// ListSharedFileMembersContinue calls ListSharedFileMembersContinueFunc.
+func (m *Sharing) ListSharedFileMembersContinue(ctx context.Context, in *dropbox.ListSharedMembersContinueInput) (*dropbox.ListSharedMembersOutput, error) {
+	m.record("ListSharedFileMembersContinue", in)
+	if m.ListSharedFileMembersContinueFunc == nil {
+		return nil, notMocked("Sharing.ListSharedFileMembersContinue")
+	}
+	return m.ListSharedFileMembersContinueFunc(ctx, in)
+}
+
+// ListSharedFileMembersIterator calls ListSharedFileMembersIteratorFunc, or
+// returns an iterator over ListSharedFileMembers and
+// ListSharedFileMembersContinue.
+func (m *Sharing) ListSharedFileMembersIterator(in *dropbox.ListSharedFileMembersInput) *dropbox.Iterator[dropbox.SharedMember] {
+	m.record("ListSharedFileMembersIterator", in)
+	if m.ListSharedFileMembersIteratorFunc != nil {
+		return m.ListSharedFileMembersIteratorFunc(in)
+	}
+	return dropbox.NewIterator(
+		func(ctx context.Context) ([]dropbox.SharedMember, string, bool, error) {
+			out, err := m.ListSharedFileMembers(ctx, in)
+			if err != nil {
+				return nil, "", false, err
+			}
+			return sharedMembers(out), out.Cursor, out.Cursor != "", nil
+		},
+		func(ctx context.Context, cursor string) ([]dropbox.SharedMember, string, bool, error) {
+			out, err := m.ListSharedFileMembersContinue(ctx, &dropbox.ListSharedMembersContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, "", false, err
+			}
+			return sharedMembers(out), out.Cursor, out.Cursor != "", nil
+		},
+	)
+}
+
+// ListSharedFolderMembers calls ListSharedFolderMembersFunc.
+func (m *Sharing) ListSharedFolderMembers(ctx context.Context, in *dropbox.ListSharedFolderMembersInput) (*dropbox.ListSharedMembersOutput, error) {
+	m.record("ListSharedFolderMembers", in)
+	if m.ListSharedFolderMembersFunc == nil {
+		return nil, notMocked("Sharing.ListSharedFolderMembers")
+	}
+	return m.ListSharedFolderMembersFunc(ctx, in)
+}
+
+// ListSharedFolderMembersContinue calls ListSharedFolderMembersContinueFunc.
+func (m *Sharing) ListSharedFolderMembersContinue(ctx context.Context, in *dropbox.ListSharedMembersContinueInput) (*dropbox.ListSharedMembersOutput, error) {
+	m.record("ListSharedFolderMembersContinue", in)
+	if m.ListSharedFolderMembersContinueFunc == nil {
+		return nil, notMocked("Sharing.ListSharedFolderMembersContinue")
+	}
+	return m.ListSharedFolderMembersContinueFunc(ctx, in)
+}
+
+// ListSharedFolderMembersIterator calls ListSharedFolderMembersIteratorFunc,
+// or returns an iterator over ListSharedFolderMembers and
+// ListSharedFolderMembersContinue.
+func (m *Sharing) ListSharedFolderMembersIterator(in *dropbox.ListSharedFolderMembersInput) *dropbox.Iterator[dropbox.SharedMember] {
+	m.record("ListSharedFolderMembersIterator", in)
+	if m.ListSharedFolderMembersIteratorFunc != nil {
+		return m.ListSharedFolderMembersIteratorFunc(in)
+	}
+	return dropbox.NewIterator(
+		func(ctx context.Context) ([]dropbox.SharedMember, string, bool, error) {
+			out, err := m.ListSharedFolderMembers(ctx, in)
+			if err != nil {
+				return nil, "", false, err
+			}
+			return sharedMembers(out), out.Cursor, out.Cursor != "", nil
+		},
+		func(ctx context.Context, cursor string) ([]dropbox.SharedMember, string, bool, error) {
+			out, err := m.ListSharedFolderMembersContinue(ctx, &dropbox.ListSharedMembersContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, "", false, err
+			}
+			return sharedMembers(out), out.Cursor, out.Cursor != "", nil
+		},
+	)
+}
+
+// ListSharedFolders calls ListSharedFoldersFunc.
+func (m *Sharing) ListSharedFolders(ctx context.Context, in *dropbox.ListSharedFolderInput) (*dropbox.ListSharedFolderOutput, error) {
+	m.record("ListSharedFolders", in)
+	if m.ListSharedFoldersFunc == nil {
+		return nil, notMocked("Sharing.ListSharedFolders")
+	}
+	return m.ListSharedFoldersFunc(ctx, in)
+}
+
+// ListSharedFoldersContinue calls ListSharedFoldersContinueFunc.
+func (m *Sharing) ListSharedFoldersContinue(ctx context.Context, in *dropbox.ListSharedFolderContinueInput) (*dropbox.ListSharedFolderOutput, error) {
+	m.record("ListSharedFoldersContinue", in)
+	if m.ListSharedFoldersContinueFunc == nil {
+		return nil, notMocked("Sharing.ListSharedFoldersContinue")
+	}
+	return m.ListSharedFoldersContinueFunc(ctx, in)
+}
+
+// ListSharedFoldersIterator calls ListSharedFoldersIteratorFunc, or returns
+// an iterator over ListSharedFolders and ListSharedFoldersContinue.
+func (m *Sharing) ListSharedFoldersIterator(in *dropbox.ListSharedFolderInput) *dropbox.Iterator[dropbox.SharedFolderMetadata] {
+	m.record("ListSharedFoldersIterator", in)
+	if m.ListSharedFoldersIteratorFunc != nil {
+		return m.ListSharedFoldersIteratorFunc(in)
+	}
+	return dropbox.NewIterator(
+		func(ctx context.Context) ([]dropbox.SharedFolderMetadata, string, bool, error) {
+			out, err := m.ListSharedFolders(ctx, in)
+			if err != nil {
+				return nil, "", false, err
+			}
+			return out.Entries, out.Cursor, out.Cursor != "", nil
+		},
+		func(ctx context.Context, cursor string) ([]dropbox.SharedFolderMetadata, string, bool, error) {
+			out, err := m.ListSharedFoldersContinue(ctx, &dropbox.ListSharedFolderContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, "", false, err
+			}
+			return out.Entries, out.Cursor, out.Cursor != "", nil
+		},
+	)
+}
+
+// sharedMembers flattens a page of members into users, then groups, then
+// invitees, as dropbox.Sharing does.
+func sharedMembers(out *dropbox.ListSharedMembersOutput) (members []dropbox.SharedMember) {
+	for i := range out.Users {
+		members = append(members, dropbox.SharedMember{User: &out.Users[i]})
+	}
+	for i := range out.Groups {
+		members = append(members, dropbox.SharedMember{Group: &out.Groups[i]})
+	}
+	for i := range out.Invitees {
+		members = append(members, dropbox.SharedMember{Invitee: &out.Invitees[i]})
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package dropboxmock
+
+import (
+	"context"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// Users is a mock dropbox.UsersAPI. Each method records its call and calls
+// the function field of the same name, returning ErrNotMocked when it is nil.
+type Users struct {
+	Recorder
+
+	GetAccountFunc        func(ctx context.Context, in *dropbox.GetAccountInput) (*dropbox.GetAccountOutput, error)
+	GetAccountBatchFunc   func(ctx context.Context, in *dropbox.GetAccountBatchInput) (dropbox.GetAccountBatchOutput, error)
+	GetCurrentAccountFunc func(ctx context.Context) (*dropbox.GetCurrentAccountOutput, error)
+	GetSpaceUsageFunc     func(ctx context.Context) (*dropbox.GetSpaceUsageOutput, error)
+}
+
+var _ dropbox.UsersAPI = (*Users)(nil)
+
+// GetAccount calls GetAccountFunc.
+func (m *Users) GetAccount(ctx context.Context, in *dropbox.GetAccountInput) (*dropbox.GetAccountOutput, error) {
+	m.record("GetAccount", in)
+	if m.GetAccountFunc == nil {
+		return nil, notMocked("Users.GetAccount")
+	}
+	return m.GetAccountFunc(ctx, in)
+}
+
+// GetAccountBatch calls GetAccountBatchFunc.
+func (m *Users) GetAccountBatch(ctx context.Context, in *dropbox.GetAccountBatchInput) (dropbox.GetAccountBatchOutput, error) {
+	m.record("GetAccountBatch", in)
+	if m.GetAccountBatchFunc == nil {
+		return nil, notMocked("Users.GetAccountBatch")
+	}
+	return m.GetAccountBatchFunc(ctx, in)
+}
+
+// GetCurrentAccount calls GetCurrentAccountFunc.
+func (m *Users) GetCurrentAccount(ctx context.Context) (*dropbox.GetCurrentAccountOutput, error) {
+	m.record("GetCurrentAccount", nil)
+	if m.GetCurrentAccountFunc == nil {
+		return nil, notMocked("Users.GetCurrentAccount")
+	}
+	return m.GetCurrentAccountFunc(ctx)
+}
+
+// GetSpaceUsage calls GetSpaceUsageFunc.
+func (m *Users) GetSpaceUsage(ctx context.Context) (*dropbox.GetSpaceUsageOutput, error) {
+	m.record("GetSpaceUsage", nil)
+	if m.GetSpaceUsageFunc == nil {
+		return nil, notMocked("Users.GetSpaceUsage")
+	}
+	return m.GetSpaceUsageFunc(ctx)
+}
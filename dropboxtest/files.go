@@ -0,0 +1,1118 @@
+package dropboxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// node is a file or folder. Deleted nodes are kept so that their revisions
+// can be listed and restored.
+type node struct {
+	id             string
+	path           string // display path
+	folder         bool
+	deleted        bool
+	revs           []*revision // oldest first, files only
+	sharedFolderID string
+}
+
+// revision of a file.
+type revision struct {
+	rev            string
+	data           []byte
+	hash           string
+	clientModified time.Time
+	serverModified time.Time
+}
+
+// current returns the latest revision of a file.
+func (n *node) current() *revision {
+	return n.revs[len(n.revs)-1]
+}
+
+// entry returns the metadata fields common to every node.
+func (n *node) entry() dropbox.EntryMetadata {
+	return dropbox.EntryMetadata{
+		Name:        path.Base(n.path),
+		PathLower:   strings.ToLower(n.path),
+		PathDisplay: n.path,
+	}
+}
+
+// metadata returns the metadata of the node in its current state.
+func (n *node) metadata() dropbox.Metadata {
+	switch {
+	case n.deleted:
+		return &dropbox.DeletedMetadata{EntryMetadata: n.entry()}
+	case n.folder:
+		return &dropbox.FolderMetadata{
+			EntryMetadata:  n.entry(),
+			ID:             n.id,
+			SharedFolderID: n.sharedFolderID,
+		}
+	default:
+		return n.fileMetadata(n.current())
+	}
+}
+
+// fileMetadata returns the metadata of a revision of the file.
+func (n *node) fileMetadata(r *revision) *dropbox.FileMetadata {
+	return &dropbox.FileMetadata{
+		EntryMetadata:  n.entry(),
+		ID:             n.id,
+		ClientModified: r.clientModified,
+		ServerModified: r.serverModified,
+		Rev:            r.rev,
+		Size:           uint64(len(r.data)),
+		ContentHash:    r.hash,
+		IsDownloadable: true,
+	}
+}
+
+// revision returns the file's revision rev, or nil.
+func (n *node) revision(rev string) *revision {
+	for _, r := range n.revs {
+		if r.rev == rev {
+			return r
+		}
+	}
+	return nil
+}
+
+// union returns the union with the given tags, nested from the outermost in.
+func union(tags ...string) interface{} {
+	return endpointError(tags...).err
+}
+
+// parent returns the lower case path of the folder containing p, which is
+// "" for the root folder.
+func parent(p string) string {
+	dir := path.Dir(p)
+	if dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// checkPath rejects a path which does not match the pattern Dropbox
+// requires. The root folder "" is only accepted when root is true.
+func checkPath(r *request, p string, root bool) error {
+	switch {
+	case p == "" && root:
+		return nil
+	case p == "":
+		return badInput(r, "path: The root folder is unsupported.")
+	case strings.HasPrefix(p, "/"), strings.HasPrefix(p, "id:"), strings.HasPrefix(p, "rev:"):
+		return nil
+	}
+	return badInput(r, fmt.Sprintf(`path: '%s' did not match pattern '(/(.|[\r\n])*)|(ns:[0-9]+(/.*)?)|(id:.*)|(rev:.*)'`, p))
+}
+
+// lookup returns the live node at p, which may be a path, an "id:" or a
+// "rev:", or the LookupError tag when there is none. The root folder is a
+// node with an empty path.
+func (s *Server) lookup(p string) (*node, string) {
+	switch {
+	case p == "":
+		return &node{folder: true}, ""
+	case strings.HasSuffix(p, "/"):
+		return nil, "malformed_path"
+	case strings.HasPrefix(p, "id:"):
+		for _, n := range s.nodes {
+			if n.id == p && !n.deleted {
+				return n, ""
+			}
+		}
+		return nil, "not_found"
+	case strings.HasPrefix(p, "rev:"):
+		for _, n := range s.nodes {
+			if !n.deleted && !n.folder && n.current().rev == strings.TrimPrefix(p, "rev:") {
+				return n, ""
+			}
+		}
+		return nil, "not_found"
+	}
+
+	n := s.nodes[strings.ToLower(p)]
+	if n == nil || n.deleted {
+		return nil, "not_found"
+	}
+	return n, ""
+}
+
+// change records that the node at the lower case path p changed, waking any
+// longpolls.
+func (s *Server) change(p string) {
+	s.changes = append(s.changes, p)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// put stores a node at its path as a change.
+func (s *Server) put(n *node) {
+	p := strings.ToLower(n.path)
+	s.nodes[p] = n
+	s.change(p)
+}
+
+// newID returns a new file or folder ID.
+func (s *Server) newID() string {
+	return fmt.Sprintf("id:dropboxtest%09d", s.next())
+}
+
+// mkdirs creates the missing parent folders of the display path p, returning
+// a WriteError tag path if one of them is a file.
+func (s *Server) mkdirs(p string) []string {
+	dir := path.Dir(p)
+	if dir == "/" {
+		return nil
+	}
+
+	if n := s.nodes[strings.ToLower(dir)]; n != nil && !n.deleted {
+		if n.folder {
+			return nil
+		}
+		return []string{"conflict", "file_ancestor"}
+	}
+
+	if tags := s.mkdirs(dir); tags != nil {
+		return tags
+	}
+	s.put(&node{id: s.newID(), path: dir, folder: true})
+	return nil
+}
+
+// used returns the bytes used by the current revision of every file.
+func (s *Server) used() (n uint64) {
+	for _, node := range s.nodes {
+		if !node.deleted && !node.folder {
+			n += uint64(len(node.current().data))
+		}
+	}
+	return
+}
+
+// commitInfo is the argument describing where and how a file is written.
+type commitInfo struct {
+	Path           string      `json:"path"`
+	Mode           interface{} `json:"mode"` // "add", "overwrite" or an "update" union with a rev
+	AutoRename     bool        `json:"autorename"`
+	ClientModified time.Time   `json:"client_modified"`
+	ContentHash    string      `json:"content_hash"`
+}
+
+// mode returns the write mode tag and, for updates, the expected rev.
+func (c *commitInfo) mode() (tag, rev string) {
+	switch m := c.Mode.(type) {
+	case string:
+		return m, ""
+	case map[string]interface{}:
+		tag, _ = m[".tag"].(string)
+		rev, _ = m["update"].(string)
+		return tag, rev
+	}
+	return "add", ""
+}
+
+// write saves data as a new revision of the file described by c, returning
+// its metadata or the tag path of a WriteError.
+func (s *Server) write(c *commitInfo, data []byte) (*dropbox.FileMetadata, []string) {
+	p := c.Path
+	if strings.HasSuffix(p, "/") || !strings.HasPrefix(p, "/") {
+		return nil, []string{"malformed_path"}
+	}
+
+	mode, rev := c.mode()
+	hash := contentHash(data)
+
+	for i := 1; ; i++ {
+		n := s.nodes[strings.ToLower(p)]
+		if n == nil || n.deleted {
+			break
+		}
+
+		var conflict string
+		switch {
+		case n.folder:
+			conflict = "folder"
+		case mode == "overwrite":
+		case mode == "update" && n.current().rev == rev:
+		case mode != "update" && n.current().hash == hash:
+			return n.fileMetadata(n.current()), nil
+		default:
+			conflict = "file"
+		}
+
+		if conflict == "" {
+			break
+		}
+		if !c.AutoRename {
+			return nil, []string{"conflict", conflict}
+		}
+		p = renamed(c.Path, i)
+	}
+
+	if s.used()+uint64(len(data)) > s.Allocated {
+		return nil, []string{"insufficient_space"}
+	}
+
+	if tags := s.mkdirs(p); tags != nil {
+		return nil, tags
+	}
+
+	n := s.nodes[strings.ToLower(p)]
+	if n == nil {
+		n = &node{id: s.newID()}
+	}
+	n.path = p
+	n.deleted = false
+
+	modified := now()
+	clientModified := c.ClientModified.UTC().Truncate(time.Second)
+	if c.ClientModified.IsZero() {
+		clientModified = modified
+	}
+
+	n.revs = append(n.revs, &revision{
+		rev:            fmt.Sprintf("%09x", s.next()),
+		data:           data,
+		hash:           hash,
+		clientModified: clientModified,
+		serverModified: modified,
+	})
+	s.put(n)
+
+	return n.fileMetadata(n.current()), nil
+}
+
+// renamed returns p with a counter added to its name, as Dropbox does to
+// avoid a conflict, such as "/notes (1).txt".
+func renamed(p string, i int) string {
+	ext := path.Ext(p)
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(p, ext), i, ext)
+}
+
+// contentHash returns the Dropbox content hash of data.
+func contentHash(data []byte) string {
+	h := dropbox.NewContentHash()
+	h.Write(data)
+	return h.String()
+}
+
+// getMetadata handles files/get_metadata.
+func (s *Server) getMetadata(r *request) (interface{}, error) {
+	var in dropbox.GetMetadataInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n, tag := s.lookup(in.Path)
+	if tag == "not_found" && in.IncludeDeleted {
+		if d := s.nodes[strings.ToLower(in.Path)]; d != nil {
+			return d.metadata(), nil
+		}
+	}
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+	return n.metadata(), nil
+}
+
+// createFolder handles files/create_folder.
+func (s *Server) createFolder(r *request) (interface{}, error) {
+	var in dropbox.CreateFolderInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(in.Path, "/") || !strings.HasPrefix(in.Path, "/") {
+		return nil, endpointError("path", "malformed_path")
+	}
+
+	if n, _ := s.lookup(in.Path); n != nil {
+		if n.folder {
+			return nil, endpointError("path", "conflict", "folder")
+		}
+		return nil, endpointError("path", "conflict", "file")
+	}
+
+	if tags := s.mkdirs(in.Path); tags != nil {
+		return nil, endpointError(append([]string{"path"}, tags...)...)
+	}
+
+	n := &node{id: s.newID(), path: in.Path, folder: true}
+	s.put(n)
+	return n.metadata(), nil
+}
+
+// remove marks the node and everything inside it as deleted.
+func (s *Server) remove(n *node) {
+	p := strings.ToLower(n.path)
+	for k, c := range s.nodes {
+		if !c.deleted && strings.HasPrefix(k, p+"/") {
+			c.deleted = true
+			s.change(k)
+		}
+	}
+	n.deleted = true
+	s.change(p)
+}
+
+// delete handles files/delete.
+func (s *Server) delete(r *request) (interface{}, error) {
+	var in dropbox.DeleteInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n, tag := s.lookup(in.Path)
+	if tag != "" {
+		return nil, endpointError("path_lookup", tag)
+	}
+
+	meta := n.metadata()
+	s.remove(n)
+	return meta, nil
+}
+
+// copy handles files/copy.
+func (s *Server) copy(r *request) (interface{}, error) {
+	return s.relocate(r, false)
+}
+
+// move handles files/move.
+func (s *Server) move(r *request) (interface{}, error) {
+	return s.relocate(r, true)
+}
+
+// relocate copies or moves a file or folder and everything inside it.
+func (s *Server) relocate(r *request, move bool) (interface{}, error) {
+	var in dropbox.MoveInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.FromPath, false); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.ToPath, false); err != nil {
+		return nil, err
+	}
+
+	src, tag := s.lookup(in.FromPath)
+	if tag != "" {
+		return nil, endpointError("from_lookup", tag)
+	}
+	if strings.HasSuffix(in.ToPath, "/") || !strings.HasPrefix(in.ToPath, "/") {
+		return nil, endpointError("to", "malformed_path")
+	}
+
+	from, to := strings.ToLower(src.path), strings.ToLower(in.ToPath)
+	if to == from || strings.HasPrefix(to, from+"/") {
+		if move {
+			return nil, endpointError("cant_move_folder_into_itself")
+		}
+		return nil, endpointError("duplicated_or_nested_paths")
+	}
+
+	if dst, _ := s.lookup(in.ToPath); dst != nil {
+		if dst.folder {
+			return nil, endpointError("to", "conflict", "folder")
+		}
+		return nil, endpointError("to", "conflict", "file")
+	}
+	if tags := s.mkdirs(in.ToPath); tags != nil {
+		return nil, endpointError(append([]string{"to"}, tags...)...)
+	}
+
+	// Relocate the folder itself before its contents, in path order.
+	var keys []string
+	for k, n := range s.nodes {
+		if !n.deleted && (k == from || strings.HasPrefix(k, from+"/")) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var root *node
+	for _, k := range keys {
+		n := s.nodes[k]
+		p := in.ToPath + n.path[len(from):]
+
+		var c *node
+		if move {
+			s.nodes[k] = &node{id: n.id, path: n.path, deleted: true}
+			s.change(k)
+			c = n
+		} else {
+			c = &node{id: s.newID(), folder: n.folder}
+			if !n.folder {
+				cur := *n.current()
+				cur.rev = fmt.Sprintf("%09x", s.next())
+				cur.serverModified = now()
+				c.revs = []*revision{&cur}
+			}
+		}
+		c.path = p
+		s.put(c)
+
+		if root == nil {
+			root = c
+		}
+	}
+
+	return root.metadata(), nil
+}
+
+// restore handles files/restore.
+func (s *Server) restore(r *request) (interface{}, error) {
+	var in dropbox.RestoreInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n := s.nodes[strings.ToLower(in.Path)]
+	if n == nil || n.folder {
+		return nil, endpointError("path_lookup", "not_found")
+	}
+
+	rev := n.revision(in.Rev)
+	if rev == nil {
+		return nil, endpointError("invalid_revision")
+	}
+
+	meta, tags := s.write(&commitInfo{
+		Path:           n.path,
+		Mode:           "overwrite",
+		ClientModified: rev.clientModified,
+	}, rev.data)
+	if tags != nil {
+		return nil, endpointError(append([]string{"path_write"}, tags...)...)
+	}
+	return meta, nil
+}
+
+// listCursor is the state of a folder listing. Cursors are never changed,
+// so an old cursor may be used again to see the same changes.
+type listCursor struct {
+	path           string // lower case, "" for the root folder
+	recursive      bool
+	includeDeleted bool
+	limit          int
+	seq            int                // number of changes seen
+	pending        []dropbox.Metadata // entries left to return
+}
+
+// inScope reports whether the lower case path p is listed by the cursor.
+func (c *listCursor) inScope(p string) bool {
+	if c.recursive {
+		return strings.HasPrefix(p, c.path+"/")
+	}
+	return parent(p) == c.path
+}
+
+// newCursor stores the cursor state and returns its value.
+func (s *Server) newCursor(c *listCursor) string {
+	cursor := "AAF" + strconv.FormatUint(s.next(), 36) + "dropboxtest"
+	s.cursors[cursor] = c
+	return cursor
+}
+
+// page returns the next page of the cursor's entries.
+func (s *Server) page(c *listCursor) *dropbox.ListFolderOutput {
+	n := len(c.pending)
+	if n > c.limit {
+		n = c.limit
+	}
+
+	next := *c
+	next.pending = c.pending[n:]
+
+	entries := c.pending[:n]
+	if entries == nil {
+		entries = []dropbox.Metadata{}
+	}
+
+	return &dropbox.ListFolderOutput{
+		Entries: entries,
+		Cursor:  s.newCursor(&next),
+		HasMore: len(next.pending) > 0,
+	}
+}
+
+// listFolderCursor validates a listing and returns its initial cursor state.
+func (s *Server) listFolderCursor(r *request) (*listCursor, error) {
+	var in dropbox.ListFolderInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, true); err != nil {
+		return nil, err
+	}
+
+	n, tag := s.lookup(in.Path)
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+	if !n.folder {
+		return nil, endpointError("path", "not_folder")
+	}
+
+	limit := int(in.Limit)
+	if limit == 0 {
+		limit = 2000
+	}
+
+	return &listCursor{
+		path:           strings.ToLower(n.path),
+		recursive:      in.Recursive,
+		includeDeleted: in.IncludeDeleted,
+		limit:          limit,
+		seq:            len(s.changes),
+	}, nil
+}
+
+// listFolder handles files/list_folder.
+func (s *Server) listFolder(r *request) (interface{}, error) {
+	c, err := s.listFolderCursor(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for k, n := range s.nodes {
+		if c.inScope(k) && (!n.deleted || c.includeDeleted) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		c.pending = append(c.pending, s.nodes[k].metadata())
+	}
+
+	return s.page(c), nil
+}
+
+// getLatestCursor handles files/list_folder/get_latest_cursor.
+func (s *Server) getLatestCursor(r *request) (interface{}, error) {
+	c, err := s.listFolderCursor(r)
+	if err != nil {
+		return nil, err
+	}
+	return &dropbox.GetLatestCursorOutput{Cursor: s.newCursor(c)}, nil
+}
+
+// changedPaths returns the paths in scope of the cursor which changed since it
+// was made, each once and in the order of their last change.
+func (s *Server) changedPaths(c *listCursor) (paths []string) {
+	last := map[string]int{}
+	for i, p := range s.changes[c.seq:] {
+		if c.inScope(p) {
+			last[p] = i
+		}
+	}
+
+	for i, p := range s.changes[c.seq:] {
+		if j, ok := last[p]; ok && i == j {
+			paths = append(paths, p)
+		}
+	}
+	return
+}
+
+// listFolderContinue handles files/list_folder/continue.
+func (s *Server) listFolderContinue(r *request) (interface{}, error) {
+	var in dropbox.ListFolderContinueInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	c, ok := s.cursors[in.Cursor]
+	if !ok {
+		return nil, endpointError("reset")
+	}
+
+	if len(c.pending) > 0 {
+		return s.page(c), nil
+	}
+
+	next := *c
+	next.pending = nil
+	for _, p := range s.changedPaths(c) {
+		next.pending = append(next.pending, s.nodes[p].metadata())
+	}
+	next.seq = len(s.changes)
+
+	return s.page(&next), nil
+}
+
+// listFolderLongpoll handles files/list_folder/longpoll, releasing the
+// server while it waits for a change.
+func (s *Server) listFolderLongpoll(r *request) (interface{}, error) {
+	var in dropbox.ListFolderLongpollInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	c, ok := s.cursors[in.Cursor]
+	if !ok {
+		return nil, endpointError("reset")
+	}
+
+	timeout := time.Duration(in.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = dropbox.MinLongpollTimeout * time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for len(c.pending) == 0 && len(s.changedPaths(c)) == 0 {
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+			s.mu.Lock()
+		case <-timer.C:
+			s.mu.Lock()
+			return &dropbox.ListFolderLongpollOutput{}, nil
+		case <-r.Context().Done():
+			s.mu.Lock()
+			return nil, r.Context().Err()
+		}
+	}
+
+	return &dropbox.ListFolderLongpollOutput{Changes: true}, nil
+}
+
+// search handles files/search, matching every word of the query against
+// the names of the entries.
+func (s *Server) search(r *request) (interface{}, error) {
+	var in dropbox.SearchInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, true); err != nil {
+		return nil, err
+	}
+
+	n, tag := s.lookup(in.Path)
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+
+	deleted := in.Mode == dropbox.SearchModeDeletedFilename
+	words := strings.Fields(strings.ToLower(in.Query))
+	scope := strings.ToLower(n.path) + "/"
+
+	var keys []string
+	for k, n := range s.nodes {
+		if n.deleted != deleted || !strings.HasPrefix(k, scope) {
+			continue
+		}
+
+		match := len(words) > 0
+		for _, w := range words {
+			if !strings.Contains(path.Base(k), w) {
+				match = false
+			}
+		}
+		if match {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	max := in.MaxResults
+	if max == 0 {
+		max = 100
+	}
+
+	out := &dropbox.SearchOutput{Matches: []*dropbox.SearchMatch{}}
+	for i := in.Start; i < uint64(len(keys)) && i < in.Start+max; i++ {
+		m := &dropbox.SearchMatch{Metadata: s.nodes[keys[i]].metadata()}
+		m.MatchType.Tag = dropbox.SearchMatchFilename
+		out.Matches = append(out.Matches, m)
+	}
+	out.Start = in.Start + uint64(len(out.Matches))
+	out.More = out.Start < uint64(len(keys))
+
+	return out, nil
+}
+
+// upload handles files/upload.
+func (s *Server) upload(r *request) (interface{}, error) {
+	var in commitInfo
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ContentHash != "" && in.ContentHash != contentHash(data) {
+		return nil, endpointError("content_hash_mismatch")
+	}
+
+	meta, tags := s.write(&in, data)
+	if tags != nil {
+		return nil, &apiError{
+			status:  http.StatusConflict,
+			summary: "path/" + strings.Join(tags, "/") + "/..",
+			err: map[string]interface{}{
+				".tag":              "path",
+				"reason":            union(tags...),
+				"upload_session_id": "",
+			},
+		}
+	}
+	return meta, nil
+}
+
+// session is an upload session.
+type session struct {
+	data   []byte
+	closed bool
+}
+
+// uploadSessionStart handles files/upload_session/start.
+func (s *Server) uploadSessionStart(r *request) (interface{}, error) {
+	var in dropbox.UploadSessionStartInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ContentHash != "" && in.ContentHash != contentHash(data) {
+		return nil, endpointError("content_hash_mismatch")
+	}
+
+	id := "pid_upload_session:" + strconv.FormatUint(s.next(), 10)
+	s.sessions[id] = &session{data: data, closed: in.Close}
+	return &dropbox.UploadSessionStartOutput{SessionID: id}, nil
+}
+
+// appendSession adds data to the session at the cursor, returning the
+// session or the UploadSessionLookupError.
+func (s *Server) appendSession(cursor dropbox.UploadSessionCursor, data []byte) (*session, interface{}) {
+	sess, ok := s.sessions[cursor.SessionID]
+	if !ok {
+		return nil, union("not_found")
+	}
+
+	if cursor.Offset != uint64(len(sess.data)) {
+		return nil, map[string]interface{}{
+			".tag":           "incorrect_offset",
+			"correct_offset": len(sess.data),
+		}
+	}
+
+	if sess.closed && len(data) > 0 {
+		return nil, union("closed")
+	}
+
+	sess.data = append(sess.data, data...)
+	return sess, nil
+}
+
+// lookupError returns an UploadSessionLookupError response, nested under
+// the given tags.
+func lookupError(e interface{}, tags ...string) *apiError {
+	tag := e.(map[string]interface{})[".tag"].(string)
+	for i := len(tags) - 1; i >= 0; i-- {
+		e = map[string]interface{}{".tag": tags[i], tags[i]: e}
+	}
+	return &apiError{
+		status:  http.StatusConflict,
+		summary: strings.Join(append(tags, tag), "/") + "/..",
+		err:     e,
+	}
+}
+
+// uploadSessionAppend handles files/upload_session/append_v2.
+func (s *Server) uploadSessionAppend(r *request) (interface{}, error) {
+	var in dropbox.UploadSessionAppendInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ContentHash != "" && in.ContentHash != contentHash(data) {
+		return nil, lookupError(union("content_hash_mismatch"))
+	}
+
+	sess, e := s.appendSession(in.Cursor, data)
+	if e != nil {
+		return nil, lookupError(e)
+	}
+
+	sess.closed = in.Close
+	return nil, nil
+}
+
+// finishSession saves the session as a file, returning its metadata or the
+// UploadSessionFinishError.
+func (s *Server) finishSession(cursor dropbox.UploadSessionCursor, commit *commitInfo, data []byte, batch bool) (*dropbox.FileMetadata, *apiError) {
+	sess, e := s.appendSession(cursor, data)
+	if e != nil {
+		return nil, lookupError(e, "lookup_failed")
+	}
+	if batch && !sess.closed {
+		return nil, lookupError(union("not_closed"), "lookup_failed")
+	}
+
+	meta, tags := s.write(commit, sess.data)
+	if tags != nil {
+		return nil, endpointError(append([]string{"path"}, tags...)...)
+	}
+
+	delete(s.sessions, cursor.SessionID)
+	return meta, nil
+}
+
+// uploadSessionFinish handles files/upload_session/finish.
+func (s *Server) uploadSessionFinish(r *request) (interface{}, error) {
+	var in struct {
+		Cursor      dropbox.UploadSessionCursor `json:"cursor"`
+		Commit      commitInfo                  `json:"commit"`
+		ContentHash string                      `json:"content_hash"`
+	}
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Commit.Path, false); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.ContentHash != "" && in.ContentHash != contentHash(data) {
+		return nil, endpointError("content_hash_mismatch")
+	}
+
+	meta, e := s.finishSession(in.Cursor, &in.Commit, data, false)
+	if e != nil {
+		return nil, e
+	}
+	return meta, nil
+}
+
+// uploadSessionFinishBatch handles files/upload_session/finish_batch_v2,
+// which always completes at once.
+func (s *Server) uploadSessionFinishBatch(r *request) (interface{}, error) {
+	var in struct {
+		Entries []struct {
+			Cursor dropbox.UploadSessionCursor `json:"cursor"`
+			Commit commitInfo                  `json:"commit"`
+		} `json:"entries"`
+	}
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	entries := []interface{}{}
+	for _, entry := range in.Entries {
+		meta, e := s.finishSession(entry.Cursor, &entry.Commit, nil, true)
+		if e != nil {
+			entries = append(entries, map[string]interface{}{".tag": "failure", "failure": e.err})
+			continue
+		}
+
+		b, err := json.Marshal(meta)
+		if err != nil {
+			return nil, err
+		}
+		var success map[string]interface{}
+		if err := json.Unmarshal(b, &success); err != nil {
+			return nil, err
+		}
+		success[".tag"] = "success"
+		entries = append(entries, success)
+	}
+
+	return map[string]interface{}{".tag": "complete", "entries": entries}, nil
+}
+
+// uploadSessionFinishBatchCheck handles files/upload_session/finish_batch/check.
+// Batches are never left running, so there are no jobs to check.
+func (s *Server) uploadSessionFinishBatchCheck(r *request) (interface{}, error) {
+	return nil, endpointError("invalid_async_job_id")
+}
+
+// downloadArg is the argument of the content-download endpoints.
+type downloadArg struct {
+	Path   string `json:"path"`
+	Rev    string `json:"rev"`
+	Format string `json:"format"`
+}
+
+// file returns the node and revision to download, or the LookupError tag.
+func (s *Server) file(in *downloadArg) (*node, *revision, string) {
+	if in.Rev != "" {
+		if n := s.nodes[strings.ToLower(in.Path)]; n != nil && !n.folder {
+			if rev := n.revision(in.Rev); rev != nil {
+				return n, rev, ""
+			}
+		}
+		return nil, nil, "not_found"
+	}
+
+	n, tag := s.lookup(in.Path)
+	if tag != "" {
+		return nil, nil, tag
+	}
+	if n.folder {
+		return nil, nil, "not_file"
+	}
+	return n, n.current(), ""
+}
+
+// download handles files/download, serving byte ranges.
+func (s *Server) download(r *request) (interface{}, error) {
+	var in downloadArg
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n, rev, tag := s.file(&in)
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+
+	return &content{result: n.fileMetadata(rev), data: rev.data}, nil
+}
+
+// thumbnailExts are the extensions of files which have thumbnails.
+var thumbnailExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".tiff": true, ".tif": true, ".gif": true, ".bmp": true,
+}
+
+// getThumbnail handles files/get_thumbnail. The image itself is served as
+// its thumbnail, whatever the format and size requested.
+func (s *Server) getThumbnail(r *request) (interface{}, error) {
+	var in downloadArg
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n, rev, tag := s.file(&in)
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+	if !thumbnailExts[strings.ToLower(path.Ext(n.path))] {
+		return nil, endpointError("unsupported_extension")
+	}
+
+	contentType := "image/jpeg"
+	if in.Format == "png" {
+		contentType = "image/png"
+	}
+	return &content{result: n.fileMetadata(rev), data: rev.data, contentType: contentType}, nil
+}
+
+// previewTypes are the content types of the previews of each extension.
+var previewTypes = map[string]string{
+	".doc": "application/pdf", ".docx": "application/pdf", ".docm": "application/pdf",
+	".ppt": "application/pdf", ".pps": "application/pdf", ".ppsx": "application/pdf",
+	".ppsm": "application/pdf", ".pptx": "application/pdf", ".pptm": "application/pdf",
+	".rtf": "application/pdf", ".pdf": "application/pdf",
+	".xls": "text/html", ".xlsx": "text/html", ".xlsm": "text/html", ".csv": "text/html",
+}
+
+// getPreview handles files/get_preview. The file itself is served as its
+// preview.
+func (s *Server) getPreview(r *request) (interface{}, error) {
+	var in downloadArg
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n, rev, tag := s.file(&in)
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+
+	contentType, ok := previewTypes[strings.ToLower(path.Ext(n.path))]
+	if !ok {
+		return nil, endpointError("unsupported_extension")
+	}
+	return &content{result: n.fileMetadata(rev), data: rev.data, contentType: contentType}, nil
+}
+
+// listRevisions handles files/list_revisions.
+func (s *Server) listRevisions(r *request) (interface{}, error) {
+	var in dropbox.ListRevisionsInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n := s.nodes[strings.ToLower(in.Path)]
+	if n == nil && strings.HasPrefix(in.Path, "id:") {
+		n, _ = s.lookup(in.Path)
+	}
+	switch {
+	case n == nil || len(n.revs) == 0 && n.deleted:
+		return nil, endpointError("path", "not_found")
+	case n.folder:
+		return nil, endpointError("path", "not_file")
+	}
+
+	limit := int(in.Limit)
+	if limit == 0 {
+		limit = 10
+	}
+
+	out := &dropbox.ListRevisionsOutput{IsDeleted: n.deleted, Entries: []*dropbox.FileMetadata{}}
+	for i := len(n.revs) - 1; i >= 0 && len(out.Entries) < limit; i-- {
+		out.Entries = append(out.Entries, n.fileMetadata(n.revs[i]))
+	}
+	return out, nil
+}
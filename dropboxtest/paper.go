@@ -0,0 +1,230 @@
+package dropboxtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// doc is a Paper document.
+type doc struct {
+	id       string
+	title    string
+	data     []byte
+	format   string // markdown, html or plain_text
+	revision int64
+	created  time.Time
+	updated  time.Time
+}
+
+// htmlTag matches the tags removed from an HTML title.
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// docTitle returns the title of a document, which is its first line.
+func docTitle(data []byte, format string) string {
+	line := strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0])
+	switch format {
+	case "markdown":
+		line = strings.TrimLeft(line, "# ")
+	case "html":
+		line = htmlTag.ReplaceAllString(line, "")
+	}
+	return strings.TrimSpace(line)
+}
+
+// createDoc handles paper/docs/create.
+func (s *Server) createDoc(r *request) (interface{}, error) {
+	var in dropbox.PaperCreateInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	switch in.ImportFormat {
+	case "markdown", "html", "plain_text":
+	default:
+		return nil, badInput(r, fmt.Sprintf("import_format: unknown tag '%s'", in.ImportFormat))
+	}
+
+	if in.ParentFolderID != "" {
+		return nil, endpointError("folder_not_found")
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	created := now()
+	d := &doc{
+		id:       "dropboxtest" + strconv.FormatUint(s.next(), 36),
+		title:    docTitle(data, in.ImportFormat),
+		data:     data,
+		format:   in.ImportFormat,
+		revision: 1,
+		created:  created,
+		updated:  created,
+	}
+	s.docs[d.id] = d
+	s.docIDs = append(s.docIDs, d.id)
+
+	return &dropbox.PaperCreateOutput{
+		DocID:    d.id,
+		Revision: d.revision,
+		Title:    d.title,
+	}, nil
+}
+
+// doc returns the document with the given ID.
+func (s *Server) doc(id string) (*doc, error) {
+	d, ok := s.docs[id]
+	if !ok {
+		return nil, endpointError("doc_not_found")
+	}
+	return d, nil
+}
+
+// downloadDoc handles paper/docs/download. The document is returned as
+// stored, whatever the export format.
+func (s *Server) downloadDoc(r *request) (interface{}, error) {
+	var in dropbox.PaperDownloadInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	var mimeType string
+	switch in.ExportFormat {
+	case dropbox.ExportFormatMarkdown:
+		mimeType = "text/x-markdown"
+	case dropbox.ExportFormatHTML:
+		mimeType = "text/html"
+	default:
+		return nil, badInput(r, fmt.Sprintf("export_format: unknown tag '%s'", in.ExportFormat))
+	}
+
+	d, err := s.doc(in.DocID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &content{
+		result: &dropbox.PaperDownloadOutput{
+			Owner:    s.Email,
+			Title:    d.title,
+			Revision: d.revision,
+			MimeType: mimeType,
+		},
+		data: d.data,
+	}, nil
+}
+
+// docsCursor is the state of a document listing.
+type docsCursor struct {
+	ids   []string // not yet listed
+	limit int
+}
+
+// docsPage returns the first limit of ids, keeping the rest for a cursor.
+func (s *Server) docsPage(ids []string, limit int) *dropbox.PaperDocsListOutput {
+	n := limit
+	if n > len(ids) {
+		n = len(ids)
+	}
+
+	out := &dropbox.PaperDocsListOutput{
+		DocIDs:  append([]string{}, ids[:n]...),
+		HasMore: n < len(ids),
+	}
+	out.Cursor.Value = "AAEdropboxtest" + strconv.FormatUint(s.next(), 36)
+	out.Cursor.Expiration = now().Add(24 * time.Hour)
+	s.docCursor[out.Cursor.Value] = &docsCursor{ids[n:], limit}
+	return out
+}
+
+// listDocs handles paper/docs/list. Documents are listed in the order they
+// were created, whatever the filter and sort order.
+func (s *Server) listDocs(r *request) (interface{}, error) {
+	var in dropbox.PaperDocsListInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if in.Limit == 0 {
+		in.Limit = 1000
+	}
+	return s.docsPage(s.docIDs, in.Limit), nil
+}
+
+// listDocsContinue handles paper/docs/list/continue.
+func (s *Server) listDocsContinue(r *request) (interface{}, error) {
+	var in dropbox.PaperDocsListContinueInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	c, ok := s.docCursor[in.Cursor]
+	if !ok {
+		return nil, endpointError("cursor_error", "invalid_cursor")
+	}
+	return s.docsPage(c.ids, c.limit), nil
+}
+
+// getFolderInfo handles paper/docs/get_folder_info. Documents are never in
+// a folder.
+func (s *Server) getFolderInfo(r *request) (interface{}, error) {
+	var in dropbox.PaperGetFolderInfoInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if _, err := s.doc(in.DocID); err != nil {
+		return nil, err
+	}
+	return struct{}{}, nil
+}
+
+// permanentlyDeleteDoc handles paper/docs/permanently_delete.
+func (s *Server) permanentlyDeleteDoc(r *request) (interface{}, error) {
+	var in dropbox.PaperPermanentlyDeleteInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if _, err := s.doc(in.DocID); err != nil {
+		return nil, err
+	}
+
+	delete(s.docs, in.DocID)
+	for i, id := range s.docIDs {
+		if id == in.DocID {
+			s.docIDs = append(s.docIDs[:i:i], s.docIDs[i+1:]...)
+			break
+		}
+	}
+	return nil, nil
+}
+
+// getDocMetadata handles paper/docs/get_metadata.
+func (s *Server) getDocMetadata(r *request) (interface{}, error) {
+	var in dropbox.PaperGetMetadataInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	d, err := s.doc(in.DocID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dropbox.PaperGetMetadataOutput{
+		DocID:           d.id,
+		Owner:           s.Email,
+		Title:           d.title,
+		CreatedDate:     d.created,
+		Status:          dropbox.PaperDocStatus{Tag: "active"},
+		Revision:        d.revision,
+		LastUpdatedDate: d.updated,
+		LastEditor:      s.Email,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package dropboxtest
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	s := NewServer()
+	rec, err := NewRecorder(path, Record)
+	require.NoError(t, err)
+	rec.Transport = s.Server.Client().Transport
+
+	config := s.Config()
+	config.HTTPClient = rec.Client()
+	c := dropbox.New(config)
+
+	upload(t, c, "/Résumé.bin", "\xff\xfe binary")
+	download(t, c, "/Résumé.bin")
+	upload(t, c, "/hello.txt", "hello")
+	upload(t, c, "/hello.txt", "hello again")
+	_, err = c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/missing"})
+	require.Error(t, err)
+
+	require.NoError(t, rec.Save())
+	s.Close()
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), Token)
+	assert.Contains(t, string(b), "hello again")
+
+	rec, err = NewRecorder(path, Replay)
+	require.NoError(t, err)
+	require.Len(t, rec.Interactions(), 5)
+
+	config.HTTPClient = rec.Client()
+	config.AccessToken = "another-token"
+	c = dropbox.New(config)
+
+	upload(t, c, "/Résumé.bin", "\xff\xfe binary")
+	assert.Equal(t, "\xff\xfe binary", download(t, c, "/Résumé.bin"))
+	assert.Equal(t, uint64(5), upload(t, c, "/hello.txt", "hello").Size)
+	assert.Equal(t, uint64(11), upload(t, c, "/hello.txt", "hello again").Size)
+
+	_, err = c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/missing"})
+	assert.True(t, errors.Is(err, dropbox.ErrNotFound))
+
+	_, err = c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/missing"})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "no recorded interaction"), err.Error())
+}
+
+func TestRecorder_token(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, TokenPath, r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh-secret", r.PostForm.Get("refresh_token"))
+		assert.Equal(t, "app-secret", r.PostForm.Get("client_secret"))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token": "sl.access-secret", "token_type": "bearer", "expires_in": 14400}`)
+	}))
+	defer s.Close()
+
+	rec, err := NewRecorder(path, Record)
+	require.NoError(t, err)
+
+	source := dropbox.NewRefreshTokenSource("app-key", "app-secret", "refresh-secret")
+	source.HTTPClient = rec.Client()
+	source.Endpoints = dropbox.NewEndpoints(s.URL)
+
+	token, err := source.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "sl.access-secret", token.AccessToken)
+	require.NoError(t, rec.Save())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"app-secret", "refresh-secret", "sl.access-secret"} {
+		assert.NotContains(t, string(b), secret)
+	}
+	assert.Contains(t, string(b), "app-key")
+
+	rec, err = NewRecorder(path, Replay)
+	require.NoError(t, err)
+	source.HTTPClient = rec.Client()
+
+	token, err = source.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Redacted, token.AccessToken)
+}
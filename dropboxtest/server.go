@@ -0,0 +1,327 @@
+// Package dropboxtest provides an in-memory Dropbox API server, and a
+// recorder which replays real Dropbox interactions, for testing code built on
+// the dropbox package without network access.
+package dropboxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// Token is the access token accepted by a Server unless another is set.
+const Token = "dropboxtest-token"
+
+// Server is an in-memory stand-in for the Dropbox API. It implements the
+// files, sharing, users and paper endpoints used by the dropbox package for a
+// single account, with the same error bodies, cursors, revisions and content
+// hashes as Dropbox. Requests for every API host are served by the one
+// server, see dropbox.NewEndpoints.
+type Server struct {
+	*httptest.Server
+
+	// Fields describing the account, which may be changed before use.
+	Token       string
+	AccountID   string
+	Email       string
+	DisplayName string
+	Allocated   uint64 // space allocation in bytes
+
+	mu      sync.Mutex
+	routes  map[string]route
+	revoked bool
+	seq     uint64
+
+	nodes    map[string]*node // by lower case path
+	changes  []string         // lower case paths, in the order they changed
+	changed  chan struct{}    // closed and replaced on each change
+	cursors  map[string]*listCursor
+	sessions map[string]*session
+
+	sharedFolders []*sharedFolder
+	folderPages   map[string]*folderCursor
+
+	docs      map[string]*doc
+	docIDs    []string // in the order they were created
+	docCursor map[string]*docsCursor
+}
+
+// NewServer starts a server, which should be closed when no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		Token:       Token,
+		AccountID:   "dbid:AAH4f99T0taONIb-OurWxbNQ6ywGRopQngc",
+		Email:       "franz@example.com",
+		DisplayName: "Franz Ferdinand",
+		Allocated:   2 << 30,
+	}
+
+	s.nodes = map[string]*node{}
+	s.changed = make(chan struct{})
+	s.cursors = map[string]*listCursor{}
+	s.sessions = map[string]*session{}
+	s.folderPages = map[string]*folderCursor{}
+	s.docs = map[string]*doc{}
+	s.docCursor = map[string]*docsCursor{}
+
+	s.routes = map[string]route{
+		"/auth/token/revoke": s.revokeToken,
+
+		"/files/get_metadata":                      s.getMetadata,
+		"/files/create_folder":                     s.createFolder,
+		"/files/delete":                            s.delete,
+		"/files/copy":                              s.copy,
+		"/files/move":                              s.move,
+		"/files/restore":                           s.restore,
+		"/files/list_folder":                       s.listFolder,
+		"/files/list_folder/continue":              s.listFolderContinue,
+		"/files/list_folder/get_latest_cursor":     s.getLatestCursor,
+		"/files/list_folder/longpoll":              s.listFolderLongpoll,
+		"/files/search":                            s.search,
+		"/files/upload":                            s.upload,
+		"/files/upload_session/start":              s.uploadSessionStart,
+		"/files/upload_session/append_v2":          s.uploadSessionAppend,
+		"/files/upload_session/finish":             s.uploadSessionFinish,
+		"/files/upload_session/finish_batch_v2":    s.uploadSessionFinishBatch,
+		"/files/upload_session/finish_batch/check": s.uploadSessionFinishBatchCheck,
+		"/files/download":                          s.download,
+		"/files/get_thumbnail":                     s.getThumbnail,
+		"/files/get_preview":                       s.getPreview,
+		"/files/list_revisions":                    s.listRevisions,
+
+		"/sharing/create_shared_link":           s.createSharedLink,
+		"/sharing/list_file_members":            s.listFileMembers,
+		"/sharing/list_file_members/continue":   s.listMembersContinue,
+		"/sharing/list_folder_members":          s.listFolderMembers,
+		"/sharing/list_folder_members/continue": s.listMembersContinue,
+		"/sharing/list_folders":                 s.listSharedFolders,
+		"/sharing/list_folders/continue":        s.listSharedFoldersContinue,
+
+		"/users/get_account":         s.getAccount,
+		"/users/get_account_batch":   s.getAccountBatch,
+		"/users/get_current_account": s.getCurrentAccount,
+		"/users/get_space_usage":     s.getSpaceUsage,
+
+		"/paper/docs/list":               s.listDocs,
+		"/paper/docs/list/continue":      s.listDocsContinue,
+		"/paper/docs/download":           s.downloadDoc,
+		"/paper/docs/get_folder_info":    s.getFolderInfo,
+		"/paper/docs/create":             s.createDoc,
+		"/paper/docs/permanently_delete": s.permanentlyDeleteDoc,
+		"/paper/docs/get_metadata":       s.getDocMetadata,
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// New starts a server which is closed at the end of the test, and returns
+// a client for it.
+func New(t testing.TB) *dropbox.Client {
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s.Client()
+}
+
+// Config returns a config for the server's account.
+func (s *Server) Config() *dropbox.Config {
+	config := dropbox.NewConfig(s.Token)
+	config.HTTPClient = s.Server.Client()
+	config.Endpoints = dropbox.NewEndpoints(s.URL)
+	return config
+}
+
+// Client returns a client for the server's account.
+func (s *Server) Client() *dropbox.Client {
+	return dropbox.New(s.Config())
+}
+
+// route handles an endpoint, returning the value to encode as the response,
+// or a *content for content-download endpoints.
+type route func(r *request) (interface{}, error)
+
+// request to an endpoint.
+type request struct {
+	*http.Request
+	name string // endpoint name, such as "files/get_metadata"
+	arg  []byte // JSON argument, from the body or the Dropbox-API-Arg header
+}
+
+// decode the argument into v, reporting malformed input as Dropbox does.
+func (r *request) decode(v interface{}) error {
+	if len(r.arg) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.arg, v); err != nil {
+		return badInput(r, "could not decode input as JSON")
+	}
+	return nil
+}
+
+// content is the response of a content-download endpoint, sent in the body
+// with its result in the Dropbox-API-Result header.
+type content struct {
+	result      interface{}
+	data        []byte
+	contentType string
+}
+
+// apiError is an error response. Endpoint errors are sent as JSON with a
+// 409 status, while malformed requests get a 400 with a plain text body.
+type apiError struct {
+	status  int
+	summary string
+	err     interface{}
+}
+
+// Error string.
+func (e *apiError) Error() string {
+	return e.summary
+}
+
+// endpointError returns the union with the given tags, nested from the
+// outermost in, such as "path", "not_found".
+func endpointError(tags ...string) *apiError {
+	var v interface{}
+	for i := len(tags) - 1; i >= 0; i-- {
+		u := map[string]interface{}{".tag": tags[i]}
+		if v != nil {
+			u[tags[i]] = v
+		}
+		v = u
+	}
+	return &apiError{
+		status:  http.StatusConflict,
+		summary: strings.Join(tags, "/") + "/..",
+		err:     v,
+	}
+}
+
+// badInput returns a 400 error for the request.
+func badInput(r *request, msg string) *apiError {
+	return &apiError{
+		status:  http.StatusBadRequest,
+		summary: fmt.Sprintf("Error in call to API function %q: %s", r.name, msg),
+	}
+}
+
+// serveHTTP authorizes and dispatches a request.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/2")
+	req := &request{Request: r, name: strings.TrimPrefix(path, "/")}
+
+	h, ok := s.routes[path]
+	if !ok {
+		writeError(w, badInput(req, "Unknown API function"))
+		return
+	}
+
+	if arg := r.Header.Get("Dropbox-API-Arg"); arg != "" {
+		req.arg = []byte(arg)
+	} else {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.arg = b
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path != "/files/list_folder/longpoll" && !s.authorized(r) {
+		writeError(w, &apiError{
+			status:  http.StatusUnauthorized,
+			summary: "invalid_access_token/..",
+			err:     map[string]interface{}{".tag": "invalid_access_token"},
+		})
+		return
+	}
+
+	v, err := h(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if c, ok := v.(*content); ok {
+		writeContent(w, r, c)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// authorized reports whether the request has the server's token.
+func (s *Server) authorized(r *http.Request) bool {
+	return !s.revoked && r.Header.Get("Authorization") == "Bearer "+s.Token
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if e.err == nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(e.status)
+		fmt.Fprint(w, e.summary)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error_summary": e.summary,
+		"error":         e.err,
+	})
+}
+
+// writeContent writes a content-download response, serving byte ranges.
+func writeContent(w http.ResponseWriter, r *http.Request, c *content) {
+	result, err := json.Marshal(c.result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := c.contentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Dropbox-API-Result", string(result))
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(c.data)))
+}
+
+// next returns the next number of the server's sequence, used for IDs,
+// revisions and cursors.
+func (s *Server) next() uint64 {
+	s.seq++
+	return s.seq
+}
+
+// now returns the time of a change, as precise as Dropbox reports it.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// revokeToken handles auth/token/revoke.
+func (s *Server) revokeToken(r *request) (interface{}, error) {
+	s.revoked = true
+	return nil, nil
+}
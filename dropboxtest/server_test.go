@@ -0,0 +1,438 @@
+package dropboxtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+var ctx = context.Background()
+
+func upload(t *testing.T, c *dropbox.Client, path, data string) *dropbox.UploadOutput {
+	out, err := c.Files.Upload(ctx, &dropbox.UploadInput{
+		Path:   path,
+		Mode:   dropbox.WriteModeOverwrite,
+		Reader: strings.NewReader(data),
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func download(t *testing.T, c *dropbox.Client, path string) string {
+	out, err := c.Files.Download(ctx, &dropbox.DownloadInput{Path: path, VerifyContentHash: true})
+	require.NoError(t, err)
+	defer out.Body.Close()
+
+	b, err := ioutil.ReadAll(out.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestServer_upload(t *testing.T) {
+	c := New(t)
+
+	out := upload(t, c, "/Notes/Hello.txt", "hello world")
+	assert.Equal(t, "Hello.txt", out.Name)
+	assert.Equal(t, "/notes/hello.txt", out.PathLower)
+	assert.Equal(t, uint64(11), out.Size)
+	assert.NotEmpty(t, out.Rev)
+
+	h := dropbox.NewContentHash()
+	h.Write([]byte("hello world"))
+	assert.Equal(t, h.String(), out.ContentHash)
+
+	assert.Equal(t, "hello world", download(t, c, "/notes/hello.txt"))
+
+	meta, err := c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/notes"})
+	require.NoError(t, err)
+	assert.Equal(t, "folder", meta.Tag())
+
+	meta, err = c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: out.ID})
+	require.NoError(t, err)
+	assert.Equal(t, out.Rev, meta.Metadata.(*dropbox.FileMetadata).Rev)
+}
+
+func TestServer_range(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/hello.txt", "hello world")
+
+	out, err := c.Files.Download(ctx, &dropbox.DownloadInput{Path: "/hello.txt", Offset: 6, Length: 3})
+	require.NoError(t, err)
+	defer out.Body.Close()
+
+	b, err := ioutil.ReadAll(out.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "wor", string(b))
+	require.NotNil(t, out.Range)
+	assert.Equal(t, int64(11), out.Range.Size)
+}
+
+func TestServer_errors(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/hello.txt", "hello")
+
+	_, err := c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/missing.txt"})
+	assert.True(t, errors.Is(err, dropbox.ErrNotFound))
+
+	var lookup *dropbox.LookupError
+	require.True(t, errors.As(err, &lookup))
+	assert.Equal(t, "not_found", lookup.Tag)
+
+	_, err = c.Files.Upload(ctx, &dropbox.UploadInput{
+		Path:   "/hello.txt",
+		Mode:   dropbox.WriteModeAdd,
+		Reader: strings.NewReader("changed"),
+	})
+	assert.True(t, errors.Is(err, dropbox.ErrConflict))
+
+	var write *dropbox.WriteError
+	require.True(t, errors.As(err, &write))
+	assert.Equal(t, "conflict", write.Tag)
+
+	var e *dropbox.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, 409, e.StatusCode)
+	assert.Equal(t, []string{"path", "conflict", "file"}, e.TagPath())
+
+	_, err = c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "hello.txt"})
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, 400, e.StatusCode)
+	assert.Contains(t, e.Summary, "did not match pattern")
+}
+
+func TestServer_autorename(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/hello.txt", "hello")
+
+	out, err := c.Files.Upload(ctx, &dropbox.UploadInput{
+		Path:       "/hello.txt",
+		AutoRename: true,
+		Reader:     strings.NewReader("changed"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/hello (1).txt", out.PathDisplay)
+}
+
+func TestServer_listFolder(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/a.txt", "a")
+	upload(t, c, "/b.txt", "b")
+	upload(t, c, "/sub/c.txt", "c")
+
+	out, err := c.Files.ListFolder(ctx, &dropbox.ListFolderInput{Path: "", Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, out.Entries, 2)
+	assert.True(t, out.HasMore)
+
+	more, err := c.Files.ListFolderContinue(ctx, &dropbox.ListFolderContinueInput{Cursor: out.Cursor})
+	require.NoError(t, err)
+	assert.Len(t, more.Entries, 1)
+	assert.False(t, more.HasMore)
+
+	_, err = c.Files.Delete(ctx, &dropbox.DeleteInput{Path: "/a.txt"})
+	require.NoError(t, err)
+	upload(t, c, "/sub/d.txt", "d")
+
+	changes, err := c.Files.ListFolderContinue(ctx, &dropbox.ListFolderContinueInput{Cursor: more.Cursor})
+	require.NoError(t, err)
+	require.Len(t, changes.Entries, 1)
+	assert.Equal(t, "deleted", changes.Entries[0].Tag())
+	assert.Equal(t, "/a.txt", changes.Entries[0].Entry().PathLower)
+
+	_, err = c.Files.ListFolderContinue(ctx, &dropbox.ListFolderContinueInput{Cursor: "bogus"})
+	var lf *dropbox.ListFolderError
+	require.True(t, errors.As(err, &lf))
+	assert.Equal(t, "reset", lf.Tag)
+}
+
+func TestServer_longpoll(t *testing.T) {
+	c := New(t)
+
+	cursor, err := c.Files.GetLatestCursor(ctx, &dropbox.ListFolderInput{Path: "", Recursive: true})
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		upload(t, c, "/hello.txt", "hello")
+	}()
+
+	out, err := c.Files.ListFolderLongpoll(ctx, &dropbox.ListFolderLongpollInput{Cursor: cursor.Cursor})
+	require.NoError(t, err)
+	assert.True(t, out.Changes)
+}
+
+func TestServer_uploadLarge(t *testing.T) {
+	c := New(t)
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	out, err := c.Files.UploadLarge(ctx, &dropbox.UploadLargeInput{
+		UploadInput: dropbox.UploadInput{
+			Path:   "/large.bin",
+			Reader: bytes.NewReader(data),
+		},
+		ChunkSize: 4096,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(len(data)), out.Size)
+	assert.Equal(t, string(data), download(t, c, "/large.bin"))
+}
+
+func TestServer_uploadLargeContentHash(t *testing.T) {
+	c := New(t)
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	hash, err := dropbox.ContentHashReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	out, err := c.Files.UploadLarge(ctx, &dropbox.UploadLargeInput{
+		UploadInput: dropbox.UploadInput{
+			Path:        "/large.bin",
+			ContentHash: hash,
+			Reader:      bytes.NewReader(data),
+		},
+		ChunkSize: 4096,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, hash, out.ContentHash)
+
+	_, err = c.Files.UploadSessionStart(ctx, &dropbox.UploadSessionStartInput{
+		ContentHash: hash,
+		Reader:      strings.NewReader("not the data"),
+	})
+	assert.Equal(t, "content_hash_mismatch/..", err.Error())
+
+	res, err := c.Files.UploadBatch(ctx, &dropbox.UploadBatchInput{
+		Files: []*dropbox.UploadInput{
+			{Path: "/a.bin", ContentHash: hash, Reader: bytes.NewReader(data)},
+			{Path: "/b.bin", ContentHash: hash, Reader: strings.NewReader("b")},
+		},
+		ChunkSize: 4096,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, res.Results[0].Err)
+	assert.IsType(t, &dropbox.IntegrityError{}, res.Results[1].Err)
+}
+
+func TestServer_uploadBatch(t *testing.T) {
+	c := New(t)
+
+	out, err := c.Files.UploadBatch(ctx, &dropbox.UploadBatchInput{
+		Files: []*dropbox.UploadInput{
+			{Path: "/a.txt", Reader: strings.NewReader("a")},
+			{Path: "/b.txt", Reader: strings.NewReader("b")},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Results, 2)
+	for _, r := range out.Results {
+		require.NoError(t, r.Err)
+		assert.Equal(t, r.Path, r.Metadata.PathLower)
+	}
+	assert.Equal(t, "b", download(t, c, "/b.txt"))
+}
+
+func TestServer_revisions(t *testing.T) {
+	c := New(t)
+	first := upload(t, c, "/hello.txt", "one")
+	upload(t, c, "/hello.txt", "two")
+
+	revs, err := c.Files.ListRevisions(ctx, &dropbox.ListRevisionsInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	require.Len(t, revs.Entries, 2)
+	assert.Equal(t, first.Rev, revs.Entries[1].Rev)
+
+	_, err = c.Files.Restore(ctx, &dropbox.RestoreInput{Path: "/hello.txt", Rev: first.Rev})
+	require.NoError(t, err)
+	assert.Equal(t, "one", download(t, c, "/hello.txt"))
+}
+
+func TestServer_copyMove(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/a/hello.txt", "hello")
+
+	_, err := c.Files.Copy(ctx, &dropbox.CopyInput{FromPath: "/a", ToPath: "/b"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", download(t, c, "/b/hello.txt"))
+
+	_, err = c.Files.Move(ctx, &dropbox.MoveInput{FromPath: "/a/hello.txt", ToPath: "/c/hi.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", download(t, c, "/c/hi.txt"))
+
+	_, err = c.Files.GetMetadata(ctx, &dropbox.GetMetadataInput{Path: "/a/hello.txt"})
+	assert.True(t, errors.Is(err, dropbox.ErrNotFound))
+
+	_, err = c.Files.Move(ctx, &dropbox.MoveInput{FromPath: "/b/hello.txt", ToPath: "/c/hi.txt"})
+	var rel *dropbox.RelocationError
+	require.True(t, errors.As(err, &rel))
+	assert.Equal(t, "to", rel.Tag)
+}
+
+func TestServer_search(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/notes/meeting notes.txt", "a")
+	upload(t, c, "/notes/todo.txt", "b")
+
+	out, err := c.Files.Search(ctx, &dropbox.SearchInput{Path: "/notes", Query: "meeting"})
+	require.NoError(t, err)
+	require.Len(t, out.Matches, 1)
+	assert.Equal(t, "meeting notes.txt", out.Matches[0].Metadata.Entry().Name)
+}
+
+func TestServer_sharing(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	upload(t, c, "/shared/hello.txt", "hello")
+	for _, p := range []string{"/shared", "/other", "/third"} {
+		if p != "/shared" {
+			_, err := c.Files.CreateFolder(ctx, &dropbox.CreateFolderInput{Path: p})
+			require.NoError(t, err)
+		}
+		_, err := s.ShareFolder(p)
+		require.NoError(t, err)
+	}
+
+	link, err := c.Sharing.CreateSharedLink(ctx, &dropbox.CreateSharedLinkInput{Path: "/shared/hello.txt"})
+	require.NoError(t, err)
+	assert.Contains(t, link.URL, "/hello.txt?dl=0")
+
+	it := c.Sharing.ListSharedFoldersIterator(&dropbox.ListSharedFolderInput{Limit: 2})
+	var names []string
+	var id string
+	for it.Next(ctx) {
+		names = append(names, it.Value().Name)
+		if id == "" {
+			id = it.Value().SharedFolderID
+		}
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"shared", "other", "third"}, names)
+
+	members, err := c.Sharing.ListSharedFolderMembers(ctx, &dropbox.ListSharedFolderMembersInput{SharedFolderID: id})
+	require.NoError(t, err)
+	require.Len(t, members.Users, 1)
+	assert.Equal(t, s.AccountID, members.Users[0].User.AccountID)
+	assert.Equal(t, dropbox.Owner, members.Users[0].AccessType.Tag)
+
+	_, err = c.Sharing.ListSharedFileMembers(ctx, &dropbox.ListSharedFileMembersInput{File: "/missing.txt"})
+	var se *dropbox.SharingError
+	assert.True(t, errors.As(err, &se))
+}
+
+func TestServer_users(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := s.Client()
+
+	account, err := c.Users.GetCurrentAccount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, s.AccountID, account.AccountID)
+	assert.Equal(t, "Franz", account.Name.GivenName)
+	assert.Equal(t, "Ferdinand", account.Name.Surname)
+
+	batch, err := c.Users.GetAccountBatch(ctx, &dropbox.GetAccountBatchInput{AccountIDs: []string{s.AccountID}})
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, s.Email, batch[0].Email)
+
+	_, err = c.Users.GetAccount(ctx, &dropbox.GetAccountInput{AccountID: "dbid:nobody"})
+	var ae *dropbox.GetAccountError
+	require.True(t, errors.As(err, &ae))
+	assert.Equal(t, "no_account", ae.Tag)
+
+	upload(t, c, "/hello.txt", "hello")
+	usage, err := c.Users.GetSpaceUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), usage.Used)
+	assert.Equal(t, s.Allocated, usage.Allocation.Allocated)
+}
+
+func TestServer_insufficientSpace(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Allocated = 4
+
+	_, err := s.Client().Files.Upload(ctx, &dropbox.UploadInput{
+		Path:   "/hello.txt",
+		Reader: strings.NewReader("hello"),
+	})
+	assert.True(t, errors.Is(err, dropbox.ErrInsufficientSpace))
+}
+
+func TestServer_paper(t *testing.T) {
+	c := New(t)
+
+	var ids []string
+	for _, title := range []string{"One", "Two", "Three"} {
+		out, err := c.Paper.Create(ctx, &dropbox.PaperCreateInput{
+			ImportFormat: "markdown",
+			Reader:       strings.NewReader("# " + title + "\n\nBody"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, title, out.Title)
+		ids = append(ids, out.DocID)
+	}
+
+	it := c.Paper.ListDocsIterator(&dropbox.PaperDocsListInput{Limit: 2})
+	var listed []string
+	for it.Next(ctx) {
+		listed = append(listed, it.Value())
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, ids, listed)
+
+	doc, err := c.Paper.Download(ctx, &dropbox.PaperDownloadInput{DocID: ids[0], ExportFormat: dropbox.ExportFormatMarkdown})
+	require.NoError(t, err)
+	defer doc.Body.Close()
+	b, err := ioutil.ReadAll(doc.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "# One\n\nBody", string(b))
+	assert.Equal(t, "One", doc.Title)
+
+	require.NoError(t, c.Paper.PermanentlyDelete(ctx, &dropbox.PaperPermanentlyDeleteInput{DocID: ids[0]}))
+	_, err = c.Paper.AlphaGetMetadata(ctx, &dropbox.PaperGetMetadataInput{DocID: ids[0]})
+	assert.True(t, errors.Is(err, dropbox.ErrNotFound))
+}
+
+func TestServer_revokeToken(t *testing.T) {
+	c := New(t)
+
+	require.NoError(t, c.Auth.RevokeToken(ctx))
+
+	_, err := c.Users.GetCurrentAccount(ctx)
+	assert.True(t, errors.Is(err, dropbox.ErrInvalidAccessToken))
+}
+
+func TestServer_watcher(t *testing.T) {
+	c := New(t)
+	upload(t, c, "/watched/old.txt", "old")
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	w := dropbox.NewWatcher(c.Files, &dropbox.ListFolderInput{Path: "/watched"}, nil)
+	events := w.Watch(ctx)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		upload(t, c, "/watched/new.txt", "new")
+	}()
+
+	e := <-events
+	assert.Equal(t, dropbox.EventChanged, e.Type)
+	assert.Equal(t, "/watched/new.txt", e.Metadata.Entry().PathLower)
+
+	cancel()
+	for range events {
+	}
+	assert.NoError(t, w.Err())
+}
@@ -0,0 +1,194 @@
+package dropboxtest
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// sharedFolder is a folder shared by the account, which is its owner.
+type sharedFolder struct {
+	id      string
+	node    *node
+	invited time.Time
+}
+
+// ShareFolder shares the folder at p, returning its shared folder ID.
+func (s *Server) ShareFolder(p string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, tag := s.lookup(p)
+	switch {
+	case tag != "":
+		return "", fmt.Errorf("dropboxtest: %s: %s", p, tag)
+	case !n.folder || n.path == "":
+		return "", fmt.Errorf("dropboxtest: %s: not a folder", p)
+	case n.sharedFolderID != "":
+		return n.sharedFolderID, nil
+	}
+
+	n.sharedFolderID = strconv.FormatUint(1000000000+s.next(), 10)
+	s.sharedFolders = append(s.sharedFolders, &sharedFolder{
+		id:      n.sharedFolderID,
+		node:    n,
+		invited: now(),
+	})
+	s.change(strings.ToLower(n.path))
+	return n.sharedFolderID, nil
+}
+
+// folderCursor is the state of a shared folder listing.
+type folderCursor struct {
+	folders []*sharedFolder // not yet listed
+	limit   int
+}
+
+// metadata returns the shared folder's metadata.
+func (f *sharedFolder) metadata() dropbox.SharedFolderMetadata {
+	var m dropbox.SharedFolderMetadata
+	m.AccessType.Tag = dropbox.Owner
+	m.Name = path.Base(f.node.path)
+	m.SharedFolderID = f.id
+	m.TimeInvited = f.invited
+	m.PathLower = strings.ToLower(f.node.path)
+	m.Policy.ACLUpdatePolicy.Tag = dropbox.ACLUpdatePolicyOwner
+	m.Policy.SharedLinkPolicy.Tag = dropbox.SharedLinkPolicyAnyone
+	m.Policy.MemberPolicy.Tag = dropbox.MemberPolicyAnyone
+	m.Policy.ResolvedMemberPolicy.Tag = dropbox.MemberPolicyAnyone
+	return m
+}
+
+// owner returns the membership of the account as owner.
+func (s *Server) owner() dropbox.UserMembershipInfo {
+	var m dropbox.UserMembershipInfo
+	m.AccessType.Tag = dropbox.Owner
+	m.User.AccountID = s.AccountID
+	m.User.SameTeam = true
+	return m
+}
+
+// createSharedLink handles sharing/create_shared_link.
+func (s *Server) createSharedLink(r *request) (interface{}, error) {
+	var in dropbox.CreateSharedLinkInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkPath(r, in.Path, false); err != nil {
+		return nil, err
+	}
+
+	n, tag := s.lookup(in.Path)
+	if tag != "" {
+		return nil, endpointError("path", tag)
+	}
+
+	out := &dropbox.CreateSharedLinkOutput{
+		URL:  fmt.Sprintf("https://www.dropbox.com/s/%s/%s?dl=0", strings.TrimPrefix(n.id, "id:"), path.Base(n.path)),
+		Path: n.path,
+	}
+	out.VisibilityModel.Tag = dropbox.Public
+	return out, nil
+}
+
+// listSharedFolders handles sharing/list_folders.
+func (s *Server) listSharedFolders(r *request) (interface{}, error) {
+	var in dropbox.ListSharedFolderInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	limit := int(in.Limit)
+	if limit == 0 {
+		limit = 1000
+	}
+
+	var folders []*sharedFolder
+	for _, f := range s.sharedFolders {
+		if !f.node.deleted {
+			folders = append(folders, f)
+		}
+	}
+	return s.sharedFolderPage(folders, limit), nil
+}
+
+// sharedFolderPage returns the first limit folders, keeping the rest for a
+// cursor. The cursor is empty on the last page.
+func (s *Server) sharedFolderPage(folders []*sharedFolder, limit int) *dropbox.ListSharedFolderOutput {
+	out := &dropbox.ListSharedFolderOutput{Entries: []dropbox.SharedFolderMetadata{}}
+	for len(folders) > 0 && len(out.Entries) < limit {
+		out.Entries = append(out.Entries, folders[0].metadata())
+		folders = folders[1:]
+	}
+
+	if len(folders) > 0 {
+		out.Cursor = "ZtkX" + strconv.FormatUint(s.next(), 36) + "dropboxtest"
+		s.folderPages[out.Cursor] = &folderCursor{folders, limit}
+	}
+	return out
+}
+
+// listSharedFoldersContinue handles sharing/list_folders/continue.
+func (s *Server) listSharedFoldersContinue(r *request) (interface{}, error) {
+	var in dropbox.ListSharedFolderContinueInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	c, ok := s.folderPages[in.Cursor]
+	if !ok {
+		return nil, endpointError("invalid_cursor")
+	}
+	return s.sharedFolderPage(c.folders, c.limit), nil
+}
+
+// listFolderMembers handles sharing/list_folder_members.
+func (s *Server) listFolderMembers(r *request) (interface{}, error) {
+	var in dropbox.ListSharedFolderMembersInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	for _, f := range s.sharedFolders {
+		if f.id == in.SharedFolderID && !f.node.deleted {
+			return &dropbox.ListSharedMembersOutput{
+				Users:    []dropbox.UserMembershipInfo{s.owner()},
+				Groups:   []dropbox.GroupMembershipInfo{},
+				Invitees: []dropbox.InviteeMembershipInfo{},
+			}, nil
+		}
+	}
+	return nil, endpointError("access_error", "invalid_id")
+}
+
+// listFileMembers handles sharing/list_file_members.
+func (s *Server) listFileMembers(r *request) (interface{}, error) {
+	var in dropbox.ListSharedFileMembersInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	n, _ := s.lookup(in.File)
+	if n == nil || n.folder {
+		return nil, endpointError("access_error", "invalid_file")
+	}
+	return &dropbox.ListSharedMembersOutput{
+		Users:    []dropbox.UserMembershipInfo{s.owner()},
+		Groups:   []dropbox.GroupMembershipInfo{},
+		Invitees: []dropbox.InviteeMembershipInfo{},
+	}, nil
+}
+
+// listMembersContinue handles the continue endpoints of file and folder
+// members. Every member fits in the first page, so no cursor is valid.
+func (s *Server) listMembersContinue(r *request) (interface{}, error) {
+	var in dropbox.ListSharedMembersContinueInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	return nil, endpointError("invalid_cursor")
+}
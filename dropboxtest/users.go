@@ -0,0 +1,92 @@
+package dropboxtest
+
+import (
+	"strings"
+
+	dropbox "github.com/tj/go-dropbox"
+)
+
+// names returns the given, familiar and surname of the account.
+func (s *Server) names() (given, surname string) {
+	given, surname = s.DisplayName, ""
+	if i := strings.LastIndex(s.DisplayName, " "); i > 0 {
+		given, surname = s.DisplayName[:i], s.DisplayName[i+1:]
+	}
+	return
+}
+
+// account returns the public details of the account.
+func (s *Server) account() *dropbox.GetAccountOutput {
+	out := &dropbox.GetAccountOutput{
+		AccountID:     s.AccountID,
+		Email:         s.Email,
+		EmailVerified: true,
+	}
+	out.Name.GivenName, out.Name.Surname = s.names()
+	out.Name.FamiliarName = out.Name.GivenName
+	out.Name.DisplayName = s.DisplayName
+	return out
+}
+
+// getAccount handles users/get_account.
+func (s *Server) getAccount(r *request) (interface{}, error) {
+	var in dropbox.GetAccountInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if in.AccountID != s.AccountID {
+		return nil, endpointError("no_account")
+	}
+	return s.account(), nil
+}
+
+// getAccountBatch handles users/get_account_batch.
+func (s *Server) getAccountBatch(r *request) (interface{}, error) {
+	var in dropbox.GetAccountBatchInput
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+
+	out := dropbox.GetAccountBatchOutput{}
+	for _, id := range in.AccountIDs {
+		if id != s.AccountID {
+			e := endpointError("no_account")
+			e.err = map[string]interface{}{".tag": "no_account", "no_account": id}
+			return nil, e
+		}
+		out = append(out, s.account())
+	}
+	return out, nil
+}
+
+// getCurrentAccount handles users/get_current_account.
+func (s *Server) getCurrentAccount(r *request) (interface{}, error) {
+	out := &dropbox.GetCurrentAccountOutput{
+		AccountID:     s.AccountID,
+		Email:         s.Email,
+		EmailVerified: true,
+		Country:       "US",
+		Locale:        "en",
+		ReferralLink:  "https://db.tt/dropboxtest",
+	}
+	out.Name.GivenName, out.Name.Surname = s.names()
+	out.Name.FamiliarName = out.Name.GivenName
+	out.Name.DisplayName = s.DisplayName
+	out.AccountType.Tag = "basic"
+	out.RootInfo.Tag = "user"
+	out.RootInfo.RootNamespaceID = "3235641"
+	out.RootInfo.HomeNamespaceID = "3235641"
+	return out, nil
+}
+
+// getSpaceUsage handles users/get_space_usage. The allocation is a union,
+// which GetSpaceUsageOutput decodes without its tag.
+func (s *Server) getSpaceUsage(r *request) (interface{}, error) {
+	return map[string]interface{}{
+		"used": s.used(),
+		"allocation": map[string]interface{}{
+			".tag":      "individual",
+			"allocated": s.Allocated,
+		},
+	}, nil
+}
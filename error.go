@@ -1,23 +1,48 @@
 package dropbox
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
 // error tag constant values
 const (
-	TooManyRequests = "too_many_requests"
+	TooManyRequests        = "too_many_requests"
+	TooManyWriteOperations = "too_many_write_operations"
+)
+
+// Sentinel errors matched by Error with errors.Is, regardless of the endpoint
+// which returned them.
+var (
+	ErrNotFound           = errors.New("dropbox: not found")
+	ErrConflict           = errors.New("dropbox: conflict")
+	ErrInsufficientSpace  = errors.New("dropbox: insufficient space")
+	ErrRateLimited        = errors.New("dropbox: rate limited")
+	ErrMalformedPath      = errors.New("dropbox: malformed path")
+	ErrNoWritePermission  = errors.New("dropbox: no write permission")
+	ErrExpiredAccessToken = errors.New("dropbox: expired access token")
+	ErrInvalidAccessToken = errors.New("dropbox: invalid access token")
 )
 
 // Error response.
 type Error struct {
-	Status     string
-	StatusCode int
-	Header     http.Header
-	Summary    string      `json:"error_summary"`
-	Message    string      `json:"user_message"` // optionally present
-	Err        interface{} `json:"error"`
+	Status      string
+	StatusCode  int
+	Header      http.Header
+	Route       string        `json:"-"` // endpoint which returned the error, such as "/files/get_metadata"
+	RequestID   string        `json:"-"` // X-Dropbox-Request-Id, for support requests
+	ContentType string        `json:"-"`
+	RetryAfter  time.Duration `json:"-"` // delay requested by Dropbox before retrying
+	Reason      string        `json:"-"` // TooManyRequests or TooManyWriteOperations when rate limited
+	Summary     string        `json:"error_summary"`
+	Message     string        `json:"user_message"` // optionally present
+	Err         interface{}   `json:"error"`
+
+	retryAfterSet bool // Dropbox requested RetryAfter, even when it is zero
 }
 
 // Error string.
@@ -56,3 +81,116 @@ func (e *Error) Tag() (tag, value string) {
 	}
 	return
 }
+
+// TagPath returns every tag of the error union from the outermost in, such as
+// ["path", "conflict", "file"] for "path/conflict/file/..".
+func (e *Error) TagPath() []string {
+	return tagPath(e.Err)
+}
+
+// Is reports whether the error matches one of the package sentinel errors.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return hasTag(e, "not_found") || hasTag(e, "doc_not_found")
+	case ErrConflict:
+		return hasTag(e, "conflict")
+	case ErrInsufficientSpace:
+		return hasTag(e, "insufficient_space") || hasTag(e, "insufficient_quota")
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			hasTag(e, TooManyRequests) ||
+			hasTag(e, TooManyWriteOperations)
+	case ErrMalformedPath:
+		return hasTag(e, "malformed_path")
+	case ErrNoWritePermission:
+		return hasTag(e, "no_write_permission")
+	case ErrExpiredAccessToken:
+		return hasTag(e, ExpiredAccessToken)
+	case ErrInvalidAccessToken:
+		return hasTag(e, "invalid_access_token")
+	}
+	return false
+}
+
+// As decodes the error into the typed error union of the endpoint which
+// returned it, such as *GetMetadataError, or into a union nested in it at any
+// depth, so a *LookupError can be taken from the error of GetMetadata,
+// Download or Delete. The unions of other endpoints do not match.
+func (e *Error) As(target interface{}) bool {
+	return decodeUnion(e, target)
+}
+
+// hasTag reports whether the error carries the given tag at any depth.
+func hasTag(e *Error, tag string) bool {
+	for _, t := range errorTags(e) {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// errorTags returns every tag in the error union and summary, such as "path"
+// and "not_found" for "path/not_found/..".
+func errorTags(e *Error) (tags []string) {
+	for _, tag := range strings.Split(e.Summary, "/") {
+		tag = strings.Trim(tag, ". ")
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return append(tags, e.TagPath()...)
+}
+
+// tagPath returns the tags of a tagged union. Variants holding a union are
+// nested under the tag name, while variants holding a struct are inlined, so
+// when there is no value under the tag the first nested union is followed,
+// such as the "reason" of a failed upload or of a rate limit.
+func tagPath(v interface{}) (tags []string) {
+	for {
+		switch u := v.(type) {
+		case string:
+			return append(tags, u)
+		case map[string]interface{}:
+			tag, ok := u[".tag"].(string)
+			if ok {
+				tags = append(tags, tag)
+				if next, ok := u[tag]; ok {
+					v = next
+					continue
+				}
+			}
+
+			if v = nestedUnion(u); v != nil {
+				continue
+			}
+		}
+		return
+	}
+}
+
+// nestedUnion returns the first field of an inlined struct which is itself a
+// tagged union, or nil.
+func nestedUnion(m map[string]interface{}) interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if u, ok := m[k].(map[string]interface{}); ok {
+			if _, ok := u[".tag"]; ok {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+// tagSummary builds an error summary such as "lookup_failed/not_found/.."
+// from a tagged union, for errors which Dropbox returns without one.
+func tagSummary(v interface{}) string {
+	return strings.Join(append(tagPath(v), ".."), "/")
+}
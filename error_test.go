@@ -1,13 +1,17 @@
 package dropbox
 
 import (
+	"errors"
+	"io"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestError(t *testing.T) {
-	c := client()
+	c := client(t)
 	out, err := c.Files.GetMetadata(ctx, &GetMetadataInput{
 		Path: "/this/does/not/exist.txt",
 	})
@@ -23,4 +27,150 @@ func TestError(t *testing.T) {
 	tag, value := derr.Tag()
 	assert.Equal(t, "path", tag, "error should indicate the path was invalid")
 	assert.Equal(t, "not_found", value, "error should indicate not found")
+	assert.True(t, errors.Is(err, ErrNotFound), "error should match ErrNotFound")
+}
+
+// conflict responds with a 409 and the given error body.
+func conflict(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, body)
+	}
+}
+
+func TestError_TagPath(t *testing.T) {
+	c := testClient(t, conflict(`{
+		"error_summary": "path/conflict/file/..",
+		"error": {".tag": "path", "reason": {".tag": "conflict", "conflict": {".tag": "file"}}, "upload_session_id": ""}
+	}`))
+
+	_, err := c.Files.Upload(ctx, &UploadInput{Path: "/a.txt"})
+	require.Error(t, err)
+
+	e := err.(*Error)
+	assert.Equal(t, []string{"path", "conflict", "file"}, e.TagPath())
+	assert.True(t, errors.Is(err, ErrConflict))
+	assert.False(t, errors.Is(err, ErrNotFound))
+
+	var upload *UploadError
+	require.True(t, errors.As(err, &upload))
+	assert.Equal(t, "path", upload.Tag)
+	assert.Equal(t, "path/conflict/file", upload.Error())
+
+	var write *WriteError
+	require.True(t, errors.As(err, &write))
+	assert.Equal(t, "conflict", write.Tag)
+	assert.Equal(t, "file", write.Conflict.Tag)
+
+	var lookup *LookupError
+	assert.False(t, errors.As(err, &lookup))
+}
+
+func TestError_As(t *testing.T) {
+	c := testClient(t, conflict(`{
+		"error_summary": "from_lookup/not_found/..",
+		"error": {".tag": "from_lookup", "from_lookup": {".tag": "not_found"}}
+	}`))
+
+	_, err := c.Files.Move(ctx, &MoveInput{FromPath: "/a", ToPath: "/b"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	var relocation *RelocationError
+	require.True(t, errors.As(err, &relocation))
+	assert.Equal(t, "from_lookup", relocation.Tag)
+	assert.Equal(t, "not_found", relocation.FromLookup.Tag)
+	assert.Nil(t, relocation.To)
+	assert.Equal(t, "from_lookup/not_found", relocation.Error())
+
+	var lookup *LookupError
+	require.True(t, errors.As(err, &lookup))
+	assert.Equal(t, "not_found", lookup.Tag)
+}
+
+func TestError_As_route(t *testing.T) {
+	c := testClient(t, conflict(`{
+		"error_summary": "path/not_found/..",
+		"error": {".tag": "path", "path": {".tag": "not_found"}}
+	}`))
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/a"})
+	require.Error(t, err)
+	assert.Equal(t, "/files/get_metadata", err.(*Error).Route)
+
+	var metadata *GetMetadataError
+	require.True(t, errors.As(err, &metadata))
+	assert.Equal(t, "path/not_found", metadata.Error())
+
+	var lookup *LookupError
+	require.True(t, errors.As(err, &lookup))
+	assert.Equal(t, "not_found", lookup.Tag)
+
+	var upload *UploadError
+	assert.False(t, errors.As(err, &upload))
+	var download *DownloadError
+	assert.False(t, errors.As(err, &download))
+	var sharing *SharingError
+	assert.False(t, errors.As(err, &sharing))
+	var finish *UploadSessionFinishError
+	assert.False(t, errors.As(err, &finish))
+	var session *UploadSessionLookupError
+	assert.False(t, errors.As(err, &session))
+	var auth *AuthError
+	assert.False(t, errors.As(err, &auth))
+}
+
+func TestError_As_uploadSession(t *testing.T) {
+	c := testClient(t, conflict(`{
+		"error_summary": "content_hash_mismatch/..",
+		"error": {".tag": "content_hash_mismatch"}
+	}`))
+
+	_, err := c.Files.UploadSessionStart(ctx, &UploadSessionStartInput{})
+	var start *UploadSessionStartError
+	require.True(t, errors.As(err, &start))
+	assert.Equal(t, "content_hash_mismatch", start.Tag)
+
+	c = testClient(t, conflict(`{
+		"error_summary": "too_many_write_operations/..",
+		"error": {".tag": "too_many_write_operations"}
+	}`))
+
+	_, err = c.Files.UploadSessionFinishBatch(ctx, &UploadSessionFinishBatchInput{})
+	var finish *UploadSessionFinishError
+	require.True(t, errors.As(err, &finish))
+	assert.Equal(t, "too_many_write_operations", finish.Tag)
+
+	c = testClient(t, conflict(`{
+		"error_summary": "invalid_async_job_id/..",
+		"error": {".tag": "invalid_async_job_id"}
+	}`))
+
+	_, err = c.Files.UploadSessionFinishBatchCheck(ctx, &AsyncJobInput{AsyncJobID: "job"})
+	var poll *PollError
+	require.True(t, errors.As(err, &poll))
+	assert.Equal(t, "invalid_async_job_id", poll.Tag)
+	assert.False(t, errors.As(err, &finish))
+}
+
+func TestError_Is(t *testing.T) {
+	cases := []struct {
+		err    *Error
+		target error
+	}{
+		{&Error{Err: map[string]interface{}{".tag": "path", "path": map[string]interface{}{".tag": "malformed_path"}}}, ErrMalformedPath},
+		{&Error{Err: map[string]interface{}{".tag": "to", "to": map[string]interface{}{".tag": "insufficient_space"}}}, ErrInsufficientSpace},
+		{&Error{Err: map[string]interface{}{".tag": "path_write", "path_write": map[string]interface{}{".tag": "no_write_permission"}}}, ErrNoWritePermission},
+		{&Error{StatusCode: 429}, ErrRateLimited},
+		{&Error{Err: map[string]interface{}{"reason": map[string]interface{}{".tag": TooManyWriteOperations}}}, ErrRateLimited},
+		{&Error{StatusCode: 401, Err: map[string]interface{}{".tag": ExpiredAccessToken}}, ErrExpiredAccessToken},
+		{&Error{Summary: "doc_not_found/.."}, ErrNotFound},
+	}
+
+	for _, c := range cases {
+		assert.True(t, errors.Is(c.err, c.target), "%v should match %v", c.err.Err, c.target)
+	}
+
+	assert.False(t, errors.Is(&Error{StatusCode: 500}, ErrRateLimited))
 }
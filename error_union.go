@@ -0,0 +1,442 @@
+package dropbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// unionError is implemented by the typed error unions which Error.As decodes.
+type unionError interface {
+	error
+	isErrorUnion()
+}
+
+var unionErrorType = reflect.TypeOf((*unionError)(nil)).Elem()
+
+// LookupError is returned when a path cannot be resolved.
+type LookupError struct {
+	Tag           string `json:".tag"`
+	MalformedPath string `json:"malformed_path,omitempty"`
+}
+
+// Error string.
+func (e *LookupError) Error() string { return unionString(e) }
+
+func (e *LookupError) isErrorUnion() {}
+
+// WriteConflictError describes what is in the way of a write.
+type WriteConflictError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *WriteConflictError) Error() string { return unionString(e) }
+
+func (e *WriteConflictError) isErrorUnion() {}
+
+// WriteError is returned when a path cannot be written to.
+type WriteError struct {
+	Tag           string              `json:".tag"`
+	MalformedPath string              `json:"malformed_path,omitempty"`
+	Conflict      *WriteConflictError `json:"conflict,omitempty"`
+}
+
+// Error string.
+func (e *WriteError) Error() string { return unionString(e) }
+
+func (e *WriteError) isErrorUnion() {}
+
+// GetMetadataError is returned by GetMetadata.
+type GetMetadataError struct {
+	Tag  string       `json:".tag"`
+	Path *LookupError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *GetMetadataError) Error() string { return unionString(e) }
+
+func (e *GetMetadataError) isErrorUnion() {}
+
+// CreateFolderError is returned by CreateFolder.
+type CreateFolderError struct {
+	Tag  string      `json:".tag"`
+	Path *WriteError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *CreateFolderError) Error() string { return unionString(e) }
+
+func (e *CreateFolderError) isErrorUnion() {}
+
+// DeleteError is returned by Delete and PermanentlyDelete.
+type DeleteError struct {
+	Tag        string       `json:".tag"`
+	PathLookup *LookupError `json:"path_lookup,omitempty"`
+	PathWrite  *WriteError  `json:"path_write,omitempty"`
+}
+
+// Error string.
+func (e *DeleteError) Error() string { return unionString(e) }
+
+func (e *DeleteError) isErrorUnion() {}
+
+// RelocationError is returned by Copy and Move.
+type RelocationError struct {
+	Tag        string       `json:".tag"`
+	FromLookup *LookupError `json:"from_lookup,omitempty"`
+	FromWrite  *WriteError  `json:"from_write,omitempty"`
+	To         *WriteError  `json:"to,omitempty"`
+}
+
+// Error string.
+func (e *RelocationError) Error() string { return unionString(e) }
+
+func (e *RelocationError) isErrorUnion() {}
+
+// RestoreError is returned by Restore.
+type RestoreError struct {
+	Tag        string       `json:".tag"`
+	PathLookup *LookupError `json:"path_lookup,omitempty"`
+	PathWrite  *WriteError  `json:"path_write,omitempty"`
+}
+
+// Error string.
+func (e *RestoreError) Error() string { return unionString(e) }
+
+func (e *RestoreError) isErrorUnion() {}
+
+// ListFolderError is returned by ListFolder, ListFolderContinue and
+// GetLatestCursor. A "reset" tag means the cursor has expired.
+type ListFolderError struct {
+	Tag  string       `json:".tag"`
+	Path *LookupError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *ListFolderError) Error() string { return unionString(e) }
+
+func (e *ListFolderError) isErrorUnion() {}
+
+// ListFolderLongpollError is returned by ListFolderLongpoll.
+type ListFolderLongpollError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *ListFolderLongpollError) Error() string { return unionString(e) }
+
+func (e *ListFolderLongpollError) isErrorUnion() {}
+
+// ListRevisionsError is returned by ListRevisions.
+type ListRevisionsError struct {
+	Tag  string       `json:".tag"`
+	Path *LookupError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *ListRevisionsError) Error() string { return unionString(e) }
+
+func (e *ListRevisionsError) isErrorUnion() {}
+
+// SearchError is returned by Search.
+type SearchError struct {
+	Tag             string       `json:".tag"`
+	Path            *LookupError `json:"path,omitempty"`
+	InvalidArgument string       `json:"invalid_argument,omitempty"`
+}
+
+// Error string.
+func (e *SearchError) Error() string { return unionString(e) }
+
+func (e *SearchError) isErrorUnion() {}
+
+// DownloadError is returned by Download and ResumeDownload.
+type DownloadError struct {
+	Tag  string       `json:".tag"`
+	Path *LookupError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *DownloadError) Error() string { return unionString(e) }
+
+func (e *DownloadError) isErrorUnion() {}
+
+// ThumbnailError is returned by GetThumbnail.
+type ThumbnailError struct {
+	Tag  string       `json:".tag"`
+	Path *LookupError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *ThumbnailError) Error() string { return unionString(e) }
+
+func (e *ThumbnailError) isErrorUnion() {}
+
+// PreviewError is returned by GetPreview.
+type PreviewError struct {
+	Tag  string       `json:".tag"`
+	Path *LookupError `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *PreviewError) Error() string { return unionString(e) }
+
+func (e *PreviewError) isErrorUnion() {}
+
+// UploadError is returned by Upload. The write failure of a "path" error is
+// in Reason.
+type UploadError struct {
+	Tag             string      `json:".tag"`
+	Reason          *WriteError `json:"reason,omitempty"`
+	UploadSessionID string      `json:"upload_session_id,omitempty"`
+}
+
+// Error string.
+func (e *UploadError) Error() string { return unionString(e) }
+
+func (e *UploadError) isErrorUnion() {}
+
+// UploadSessionStartError is returned by UploadSessionStart.
+type UploadSessionStartError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *UploadSessionStartError) Error() string { return unionString(e) }
+
+func (e *UploadSessionStartError) isErrorUnion() {}
+
+// UploadSessionLookupError is returned by UploadSessionAppend, and by
+// UploadSessionFinish when the session cannot be found.
+type UploadSessionLookupError struct {
+	Tag           string `json:".tag"`
+	CorrectOffset uint64 `json:"correct_offset,omitempty"`
+}
+
+// Error string.
+func (e *UploadSessionLookupError) Error() string { return unionString(e) }
+
+func (e *UploadSessionLookupError) isErrorUnion() {}
+
+// UploadSessionFinishError is returned by UploadSessionFinish and
+// UploadSessionFinishBatch, and for each failed entry of a batch.
+type UploadSessionFinishError struct {
+	Tag          string                    `json:".tag"`
+	LookupFailed *UploadSessionLookupError `json:"lookup_failed,omitempty"`
+	Path         *WriteError               `json:"path,omitempty"`
+}
+
+// Error string.
+func (e *UploadSessionFinishError) Error() string { return unionString(e) }
+
+func (e *UploadSessionFinishError) isErrorUnion() {}
+
+// PollError is returned by UploadSessionFinishBatchCheck.
+type PollError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *PollError) Error() string { return unionString(e) }
+
+func (e *PollError) isErrorUnion() {}
+
+// SharingAccessError is returned when a shared file or folder cannot be
+// accessed.
+type SharingAccessError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *SharingAccessError) Error() string { return unionString(e) }
+
+func (e *SharingAccessError) isErrorUnion() {}
+
+// SharingUserError is returned when the current user cannot use sharing.
+type SharingUserError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *SharingUserError) Error() string { return unionString(e) }
+
+func (e *SharingUserError) isErrorUnion() {}
+
+// SharingError is returned by the Sharing endpoints.
+type SharingError struct {
+	Tag         string              `json:".tag"`
+	Path        *LookupError        `json:"path,omitempty"`
+	AccessError *SharingAccessError `json:"access_error,omitempty"`
+	UserError   *SharingUserError   `json:"user_error,omitempty"`
+}
+
+// Error string.
+func (e *SharingError) Error() string { return unionString(e) }
+
+func (e *SharingError) isErrorUnion() {}
+
+// PaperError is returned by the Paper endpoints.
+type PaperError struct {
+	Tag string `json:".tag"`
+}
+
+// Error string.
+func (e *PaperError) Error() string { return unionString(e) }
+
+func (e *PaperError) isErrorUnion() {}
+
+// GetAccountError is returned by GetAccount and GetAccountBatch. For a batch
+// NoAccount is the account which does not exist.
+type GetAccountError struct {
+	Tag       string `json:".tag"`
+	NoAccount string `json:"no_account,omitempty"`
+}
+
+// Error string.
+func (e *GetAccountError) Error() string { return unionString(e) }
+
+func (e *GetAccountError) isErrorUnion() {}
+
+// AuthError is returned with a 401 status when the access token is invalid.
+type AuthError struct {
+	Tag           string `json:".tag"`
+	RequiredScope string `json:"required_scope,omitempty"`
+}
+
+// Error string.
+func (e *AuthError) Error() string { return unionString(e) }
+
+func (e *AuthError) isErrorUnion() {}
+
+// unionString formats a typed error union as its tag path, such as
+// "path/not_found".
+func unionString(u unionError) string {
+	v := reflect.ValueOf(u).Elem()
+	tags := []string{v.FieldByName("Tag").String()}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr || f.IsNil() {
+			continue
+		}
+		if nested, ok := f.Interface().(unionError); ok {
+			tags = append(tags, nested.Error())
+			break
+		}
+	}
+
+	return strings.Join(tags, "/")
+}
+
+// routeUnions maps each endpoint to the typed union of the errors it returns.
+var routeUnions = map[string]reflect.Type{
+	"/files/get_metadata":                      reflect.TypeOf(GetMetadataError{}),
+	"/files/create_folder":                     reflect.TypeOf(CreateFolderError{}),
+	"/files/delete":                            reflect.TypeOf(DeleteError{}),
+	"/files/copy":                              reflect.TypeOf(RelocationError{}),
+	"/files/move":                              reflect.TypeOf(RelocationError{}),
+	"/files/restore":                           reflect.TypeOf(RestoreError{}),
+	"/files/list_folder":                       reflect.TypeOf(ListFolderError{}),
+	"/files/list_folder/continue":              reflect.TypeOf(ListFolderError{}),
+	"/files/list_folder/get_latest_cursor":     reflect.TypeOf(ListFolderError{}),
+	"/files/list_folder/longpoll":              reflect.TypeOf(ListFolderLongpollError{}),
+	"/files/list_revisions":                    reflect.TypeOf(ListRevisionsError{}),
+	"/files/search":                            reflect.TypeOf(SearchError{}),
+	"/files/download":                          reflect.TypeOf(DownloadError{}),
+	"/files/get_thumbnail":                     reflect.TypeOf(ThumbnailError{}),
+	"/files/get_preview":                       reflect.TypeOf(PreviewError{}),
+	"/files/upload":                            reflect.TypeOf(UploadError{}),
+	"/files/upload_session/start":              reflect.TypeOf(UploadSessionStartError{}),
+	"/files/upload_session/append_v2":          reflect.TypeOf(UploadSessionLookupError{}),
+	"/files/upload_session/finish":             reflect.TypeOf(UploadSessionFinishError{}),
+	"/files/upload_session/finish_batch_v2":    reflect.TypeOf(UploadSessionFinishError{}),
+	"/files/upload_session/finish_batch/check": reflect.TypeOf(PollError{}),
+	"/sharing/create_shared_link":              reflect.TypeOf(SharingError{}),
+	"/sharing/list_file_members":               reflect.TypeOf(SharingError{}),
+	"/sharing/list_file_members/continue":      reflect.TypeOf(SharingError{}),
+	"/sharing/list_folder_members":             reflect.TypeOf(SharingError{}),
+	"/sharing/list_folder_members/continue":    reflect.TypeOf(SharingError{}),
+	"/sharing/list_folders":                    reflect.TypeOf(SharingError{}),
+	"/sharing/list_folders/continue":           reflect.TypeOf(SharingError{}),
+	"/paper/docs/create":                       reflect.TypeOf(PaperError{}),
+	"/paper/docs/download":                     reflect.TypeOf(PaperError{}),
+	"/paper/docs/get_folder_info":              reflect.TypeOf(PaperError{}),
+	"/paper/docs/get_metadata":                 reflect.TypeOf(PaperError{}),
+	"/paper/docs/list":                         reflect.TypeOf(PaperError{}),
+	"/paper/docs/list/continue":                reflect.TypeOf(PaperError{}),
+	"/paper/docs/permanently_delete":           reflect.TypeOf(PaperError{}),
+	"/users/get_account":                       reflect.TypeOf(GetAccountError{}),
+	"/users/get_account_batch":                 reflect.TypeOf(GetAccountError{}),
+}
+
+// decodeUnion decodes the error e into target, which must be a pointer to one
+// of the typed error unions. The error is decoded as the union of the
+// endpoint which returned it, or as an AuthError for a 401, and target may be
+// that union or one nested in it, so that the unions of other endpoints never
+// match.
+func decodeUnion(e *Error, target interface{}) bool {
+	t := reflect.ValueOf(target)
+	if t.Kind() != reflect.Ptr || t.IsNil() {
+		return false
+	}
+
+	typ := t.Type().Elem()
+	if typ.Kind() != reflect.Ptr || !typ.Implements(unionErrorType) {
+		return false
+	}
+
+	outer, ok := routeUnions[e.Route]
+	if e.StatusCode == http.StatusUnauthorized {
+		outer, ok = reflect.TypeOf(AuthError{}), true
+	}
+	if !ok {
+		return false
+	}
+
+	v := e.Err
+	if s, ok := v.(string); ok {
+		v = map[string]interface{}{".tag": s}
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return false
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+
+	u := reflect.New(outer)
+	if err := json.Unmarshal(b, u.Interface()); err != nil {
+		return false
+	}
+
+	if u = findUnion(u, typ); !u.IsValid() {
+		return false
+	}
+
+	t.Elem().Set(u)
+	return true
+}
+
+// findUnion returns u, or the first union nested in it, of type typ. Only the
+// variant which is set holds a non-nil union.
+func findUnion(u reflect.Value, typ reflect.Type) reflect.Value {
+	if u.Type() == typ {
+		return u
+	}
+
+	v := u.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr || f.IsNil() || !f.Type().Implements(unionErrorType) {
+			continue
+		}
+		if found := findUnion(f, typ); found.IsValid() {
+			return found
+		}
+	}
+	return reflect.Value{}
+}
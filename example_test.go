@@ -1,6 +1,7 @@
 package dropbox_test
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -14,7 +15,7 @@ func Example() {
 
 	file, _ := os.Open("Readme.md")
 
-	d.Files.Upload(&dropbox.UploadInput{
+	d.Files.Upload(context.Background(), &dropbox.UploadInput{
 		Path:   "Readme.md",
 		Reader: file,
 		Mute:   true,
@@ -25,7 +26,7 @@ func Example() {
 func Example_files() {
 	files := dropbox.NewFiles(dropbox.NewConfig("<token>"))
 
-	out, _ := files.Download(&dropbox.DownloadInput{
+	out, _ := files.Download(context.Background(), &dropbox.DownloadInput{
 		Path: "Readme.md",
 	})
 
@@ -35,6 +36,24 @@ func Example_files() {
 // Example using the Users client directly.
 func Example_users() {
 	users := dropbox.NewUsers(dropbox.NewConfig("<token>"))
-	out, _ := users.GetCurrentAccount()
+	out, _ := users.GetCurrentAccount(context.Background())
+	fmt.Printf("%v\n", out)
+}
+
+// Example authorizing a CLI tool, which receives the redirect on localhost and
+// keeps its access token fresh with the returned refresh token.
+func Example_auth() {
+	oauth := &dropbox.OAuthConfig{
+		AppKey:        "<app key>",
+		OfflineAccess: true,
+	}
+
+	config, _ := oauth.AuthorizeLoopback(context.Background(), "127.0.0.1:53682", func(url string) error {
+		fmt.Println("Visit", url)
+		return nil
+	})
+
+	d := dropbox.New(config)
+	out, _ := d.Users.GetCurrentAccount(context.Background())
 	fmt.Printf("%v\n", out)
 }
@@ -1,9 +1,15 @@
 package dropbox
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -75,25 +81,204 @@ type FileSharingInfo struct {
 	ModifiedBy           string `json:"modified_by,omitempty"`
 }
 
-// Metadata for a file or folder.
-type Metadata struct {
-	Tag            string           `json:".tag"`
-	Name           string           `json:"name"`
-	PathLower      string           `json:"path_lower"`
-	PathDisplay    string           `json:"path_display"`
-	ClientModified time.Time        `json:"client_modified"`
-	ServerModified time.Time        `json:"server_modified"`
-	Rev            string           `json:"rev"`
-	Size           uint64           `json:"size"`
-	ID             string           `json:"id"`
-	MediaInfo      *MediaInfo       `json:"media_info,omitempty"`
-	SharingInfo    *FileSharingInfo `json:"sharing_info,omitempty"`
+// FolderSharingInfo for a folder which is a shared folder or is contained in
+// one.
+type FolderSharingInfo struct {
+	ReadOnly             bool   `json:"read_only"`
+	ParentSharedFolderID string `json:"parent_shared_folder_id,omitempty"`
+	SharedFolderID       string `json:"shared_folder_id,omitempty"`
+	TraverseOnly         bool   `json:"traverse_only"`
+	NoAccess             bool   `json:"no_access"`
+}
+
+// SymlinkInfo for a file which is a symlink.
+type SymlinkInfo struct {
+	Target string `json:"target"`
+}
+
+// ExportInfo for a file which cannot be downloaded directly and must be
+// exported instead, such as a Google Doc.
+type ExportInfo struct {
+	ExportAs      string   `json:"export_as,omitempty"`
+	ExportOptions []string `json:"export_options,omitempty"`
+}
+
+// FileLockInfo describes the lock on a locked file.
+type FileLockInfo struct {
+	IsLockholder        bool      `json:"is_lockholder"`
+	LockholderName      string    `json:"lockholder_name,omitempty"`
+	LockholderAccountID string    `json:"lockholder_account_id,omitempty"`
+	Created             time.Time `json:"created,omitempty"`
+}
+
+// PropertyField is a single custom property of a file or folder.
+type PropertyField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PropertyGroup is a set of custom properties belonging to a template.
+type PropertyGroup struct {
+	TemplateID string          `json:"template_id"`
+	Fields     []PropertyField `json:"fields"`
+}
+
+// TemplateFilter selects the property groups returned with metadata.
+type TemplateFilter struct {
+	Tag        string   `json:".tag"`
+	FilterSome []string `json:"filter_some,omitempty"`
+}
+
+// FilterSomeTemplates returns a TemplateFilter for the given template IDs.
+func FilterSomeTemplates(templateIDs ...string) *TemplateFilter {
+	return &TemplateFilter{Tag: "filter_some", FilterSome: templateIDs}
+}
+
+// Metadata for a file, folder or deleted entry, which is one of
+// *FileMetadata, *FolderMetadata or *DeletedMetadata. Use a type switch to
+// tell them apart.
+type Metadata interface {
+	// Tag returns the ".tag" of the entry: "file", "folder" or "deleted".
+	Tag() string
+	// Entry returns the fields common to every kind of entry.
+	Entry() *EntryMetadata
+}
+
+// EntryMetadata holds the fields common to every kind of Metadata.
+type EntryMetadata struct {
+	Name                 string `json:"name"`
+	PathLower            string `json:"path_lower,omitempty"`
+	PathDisplay          string `json:"path_display,omitempty"`
+	ParentSharedFolderID string `json:"parent_shared_folder_id,omitempty"` // deprecated, use SharingInfo
+	PreviewURL           string `json:"preview_url,omitempty"`
+}
+
+// Entry returns the common fields.
+func (m *EntryMetadata) Entry() *EntryMetadata {
+	return m
+}
+
+// FileMetadata for a file.
+type FileMetadata struct {
+	EntryMetadata
+	ID                       string           `json:"id"`
+	ClientModified           time.Time        `json:"client_modified"`
+	ServerModified           time.Time        `json:"server_modified"`
+	Rev                      string           `json:"rev"`
+	Size                     uint64           `json:"size"`
+	ContentHash              string           `json:"content_hash,omitempty"`
+	IsDownloadable           bool             `json:"is_downloadable"`
+	MediaInfo                *MediaInfo       `json:"media_info,omitempty"`
+	SymlinkInfo              *SymlinkInfo     `json:"symlink_info,omitempty"`
+	SharingInfo              *FileSharingInfo `json:"sharing_info,omitempty"`
+	ExportInfo               *ExportInfo      `json:"export_info,omitempty"`
+	PropertyGroups           []PropertyGroup  `json:"property_groups,omitempty"`
+	HasExplicitSharedMembers bool             `json:"has_explicit_shared_members,omitempty"`
+	FileLockInfo             *FileLockInfo    `json:"file_lock_info,omitempty"`
+}
+
+// Tag returns "file".
+func (m *FileMetadata) Tag() string { return "file" }
+
+// MarshalJSON includes the ".tag" of the entry.
+func (m *FileMetadata) MarshalJSON() ([]byte, error) {
+	type file FileMetadata
+	return json.Marshal(struct {
+		Tag string `json:".tag"`
+		*file
+	}{m.Tag(), (*file)(m)})
+}
+
+// FolderMetadata for a folder.
+type FolderMetadata struct {
+	EntryMetadata
+	ID             string             `json:"id"`
+	SharedFolderID string             `json:"shared_folder_id,omitempty"` // deprecated, use SharingInfo
+	SharingInfo    *FolderSharingInfo `json:"sharing_info,omitempty"`
+	PropertyGroups []PropertyGroup    `json:"property_groups,omitempty"`
+}
+
+// Tag returns "folder".
+func (m *FolderMetadata) Tag() string { return "folder" }
+
+// MarshalJSON includes the ".tag" of the entry.
+func (m *FolderMetadata) MarshalJSON() ([]byte, error) {
+	type folder FolderMetadata
+	return json.Marshal(struct {
+		Tag string `json:".tag"`
+		*folder
+	}{m.Tag(), (*folder)(m)})
+}
+
+// DeletedMetadata for a file or folder which has been deleted, as listed by
+// ListFolder with IncludeDeleted set.
+type DeletedMetadata struct {
+	EntryMetadata
+}
+
+// Tag returns "deleted".
+func (m *DeletedMetadata) Tag() string { return "deleted" }
+
+// MarshalJSON includes the ".tag" of the entry.
+func (m *DeletedMetadata) MarshalJSON() ([]byte, error) {
+	type deleted DeletedMetadata
+	return json.Marshal(struct {
+		Tag string `json:".tag"`
+		*deleted
+	}{m.Tag(), (*deleted)(m)})
+}
+
+// decodeMetadata decodes an entry according to its ".tag".
+func decodeMetadata(b []byte) (Metadata, error) {
+	var union struct {
+		Tag string `json:".tag"`
+	}
+	if err := json.Unmarshal(b, &union); err != nil {
+		return nil, err
+	}
+
+	var m Metadata
+	switch union.Tag {
+	case "file":
+		m = &FileMetadata{}
+	case "folder":
+		m = &FolderMetadata{}
+	case "deleted":
+		m = &DeletedMetadata{}
+	default:
+		return nil, fmt.Errorf("dropbox: unknown metadata tag %q", union.Tag)
+	}
+
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeMetadataList decodes a list of entries according to their ".tag".
+func decodeMetadataList(raw []json.RawMessage) ([]Metadata, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	list := make([]Metadata, len(raw))
+	for i, b := range raw {
+		m, err := decodeMetadata(b)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = m
+	}
+	return list, nil
 }
 
 // GetMetadataInput request input.
 type GetMetadataInput struct {
-	Path             string `json:"path"`
-	IncludeMediaInfo bool   `json:"include_media_info"`
+	Path                            string          `json:"path"`
+	IncludeMediaInfo                bool            `json:"include_media_info"`
+	IncludeDeleted                  bool            `json:"include_deleted,omitempty"`
+	IncludeHasExplicitSharedMembers bool            `json:"include_has_explicit_shared_members,omitempty"`
+	IncludePropertyGroups           *TemplateFilter `json:"include_property_groups,omitempty"`
 }
 
 // GetMetadataOutput request output.
@@ -101,6 +286,12 @@ type GetMetadataOutput struct {
 	Metadata
 }
 
+// UnmarshalJSON decodes the metadata according to its ".tag".
+func (o *GetMetadataOutput) UnmarshalJSON(b []byte) (err error) {
+	o.Metadata, err = decodeMetadata(b)
+	return
+}
+
 // GetMetadata returns the metadata for a file or folder.
 func (c *Files) GetMetadata(ctx context.Context, in *GetMetadataInput) (out *GetMetadataOutput, err error) {
 	body, err := c.call(ctx, "/files/get_metadata", in)
@@ -147,6 +338,12 @@ type DeleteOutput struct {
 	Metadata
 }
 
+// UnmarshalJSON decodes the metadata according to its ".tag".
+func (o *DeleteOutput) UnmarshalJSON(b []byte) (err error) {
+	o.Metadata, err = decodeMetadata(b)
+	return
+}
+
 // Delete a file or folder and its contents.
 func (c *Files) Delete(ctx context.Context, in *DeleteInput) (out *DeleteOutput, err error) {
 	body, err := c.call(ctx, "/files/delete", in)
@@ -186,6 +383,12 @@ type CopyOutput struct {
 	Metadata
 }
 
+// UnmarshalJSON decodes the metadata according to its ".tag".
+func (o *CopyOutput) UnmarshalJSON(b []byte) (err error) {
+	o.Metadata, err = decodeMetadata(b)
+	return
+}
+
 // Copy a file or folder to a different location.
 func (c *Files) Copy(ctx context.Context, in *CopyInput) (out *CopyOutput, err error) {
 	body, err := c.call(ctx, "/files/copy", in)
@@ -209,6 +412,12 @@ type MoveOutput struct {
 	Metadata
 }
 
+// UnmarshalJSON decodes the metadata according to its ".tag".
+func (o *MoveOutput) UnmarshalJSON(b []byte) (err error) {
+	o.Metadata, err = decodeMetadata(b)
+	return
+}
+
 // Move a file or folder to a different location.
 func (c *Files) Move(ctx context.Context, in *MoveInput) (out *MoveOutput, err error) {
 	body, err := c.call(ctx, "/files/move", in)
@@ -229,7 +438,7 @@ type RestoreInput struct {
 
 // RestoreOutput request output.
 type RestoreOutput struct {
-	Metadata
+	FileMetadata
 }
 
 // Restore a file to a specific revision.
@@ -244,19 +453,45 @@ func (c *Files) Restore(ctx context.Context, in *RestoreInput) (out *RestoreOutp
 	return
 }
 
-// ListFolderInput request input.
+// ListFolderInput request input. IncludeMountedFolders and
+// IncludeNonDownloadableFiles default to true when nil.
 type ListFolderInput struct {
-	Path             string `json:"path"`
-	Recursive        bool   `json:"recursive"`
-	IncludeMediaInfo bool   `json:"include_media_info"`
-	IncludeDeleted   bool   `json:"include_deleted"`
+	Path                            string          `json:"path"`
+	Recursive                       bool            `json:"recursive"`
+	IncludeMediaInfo                bool            `json:"include_media_info"`
+	IncludeDeleted                  bool            `json:"include_deleted"`
+	IncludeHasExplicitSharedMembers bool            `json:"include_has_explicit_shared_members,omitempty"`
+	IncludeMountedFolders           *bool           `json:"include_mounted_folders,omitempty"`
+	IncludeNonDownloadableFiles     *bool           `json:"include_non_downloadable_files,omitempty"`
+	IncludePropertyGroups           *TemplateFilter `json:"include_property_groups,omitempty"`
+	Limit                           uint32          `json:"limit,omitempty"`
 }
 
 // ListFolderOutput request output.
 type ListFolderOutput struct {
-	Cursor  string `json:"cursor"`
-	HasMore bool   `json:"has_more"`
-	Entries []*Metadata
+	Cursor  string     `json:"cursor"`
+	HasMore bool       `json:"has_more"`
+	Entries []Metadata `json:"entries"`
+}
+
+// UnmarshalJSON decodes each entry according to its ".tag".
+func (o *ListFolderOutput) UnmarshalJSON(b []byte) error {
+	var out struct {
+		Cursor  string            `json:"cursor"`
+		HasMore bool              `json:"has_more"`
+		Entries []json.RawMessage `json:"entries"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return err
+	}
+
+	entries, err := decodeMetadataList(out.Entries)
+	if err != nil {
+		return err
+	}
+
+	*o = ListFolderOutput{Cursor: out.Cursor, HasMore: out.HasMore, Entries: entries}
+	return nil
 }
 
 // ListFolder returns the metadata for a file or folder.
@@ -290,6 +525,109 @@ func (c *Files) ListFolderContinue(ctx context.Context, in *ListFolderContinueIn
 	return
 }
 
+// GetLatestCursorOutput request output.
+type GetLatestCursorOutput struct {
+	Cursor string `json:"cursor"`
+}
+
+// GetLatestCursor returns a cursor for the folder's current state without
+// listing its contents, for use with ListFolderLongpoll and
+// ListFolderContinue to see only later changes.
+func (c *Files) GetLatestCursor(ctx context.Context, in *ListFolderInput) (out *GetLatestCursorOutput, err error) {
+	in.Path = normalizePath(in.Path)
+
+	body, err := c.call(ctx, "/files/list_folder/get_latest_cursor", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// Longpoll timeout bounds, in seconds.
+const (
+	MinLongpollTimeout = 30
+	MaxLongpollTimeout = 480
+)
+
+// ListFolderLongpollInput request input.
+type ListFolderLongpollInput struct {
+	Cursor  string `json:"cursor"`
+	Timeout uint64 `json:"timeout,omitempty"` // seconds, from 30 to 480, defaults to 30
+}
+
+// ListFolderLongpollOutput request output.
+type ListFolderLongpollOutput struct {
+	Changes bool   `json:"changes"`
+	Backoff uint64 `json:"backoff,omitempty"` // seconds to wait before polling again
+}
+
+// ListFolderLongpoll blocks until the folder of the cursor changes or the
+// timeout passes, then reports whether ListFolderContinue has changes to
+// return. Dropbox adds up to 90 seconds of jitter to the timeout, so the
+// HTTPClient's own timeout must allow for it. When Dropbox requests a backoff,
+// the next call waits for it to pass before polling.
+func (c *Files) ListFolderLongpoll(ctx context.Context, in *ListFolderLongpollInput) (out *ListFolderLongpollOutput, err error) {
+	if in.Timeout != 0 && in.Timeout < MinLongpollTimeout {
+		in.Timeout = MinLongpollTimeout
+	}
+	if in.Timeout > MaxLongpollTimeout {
+		in.Timeout = MaxLongpollTimeout
+	}
+
+	if err = c.longpoll.wait(ctx); err != nil {
+		return
+	}
+
+	body, err := c.rpc(ctx, "notify", "/files/list_folder/longpoll", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	if err = json.NewDecoder(body).Decode(&out); err != nil {
+		return
+	}
+
+	c.longpoll.set(time.Duration(out.Backoff) * time.Second)
+	return
+}
+
+// backoff tracks a period during which requests should not be made.
+type backoff struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// set the backoff to end after d.
+func (b *backoff) set(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.until = time.Now().Add(d)
+}
+
+// wait until the backoff ends or the context is done.
+func (b *backoff) wait(ctx context.Context) error {
+	b.mu.Lock()
+	d := time.Until(b.until)
+	b.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // SearchMode determines how a search is performed.
 type SearchMode string
 
@@ -315,7 +653,30 @@ type SearchMatch struct {
 	MatchType struct {
 		Tag SearchMatchType `json:".tag"`
 	} `json:"match_type"`
-	Metadata *Metadata `json:"metadata"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// UnmarshalJSON decodes the metadata according to its ".tag".
+func (m *SearchMatch) UnmarshalJSON(b []byte) error {
+	var match struct {
+		MatchType struct {
+			Tag SearchMatchType `json:".tag"`
+		} `json:"match_type"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(b, &match); err != nil {
+		return err
+	}
+
+	m.MatchType.Tag = match.MatchType.Tag
+	if match.Metadata == nil {
+		m.Metadata = nil
+		return nil
+	}
+
+	var err error
+	m.Metadata, err = decodeMetadata(match.Metadata)
+	return err
 }
 
 // SearchInput request input.
@@ -352,55 +713,524 @@ func (c *Files) Search(ctx context.Context, in *SearchInput) (out *SearchOutput,
 	return
 }
 
-// UploadInput request input.
+// UploadInput request input. Set VerifyContentHash to check the content hash
+// of the data sent against the uploaded file, which also has Dropbox check
+// it when the reader is an io.Seeker.
 type UploadInput struct {
+	Path              string    `json:"path"`
+	Mode              WriteMode `json:"mode"`
+	AutoRename        bool      `json:"autorename"`
+	Mute              bool      `json:"mute"`
+	ClientModified    time.Time `json:"client_modified,omitempty"`
+	ContentHash       string    `json:"content_hash,omitempty"` // Dropbox rejects the upload if the data does not match
+	Reader            io.Reader `json:"-"`
+	VerifyContentHash bool      `json:"-"`
+}
+
+// MarshalJSON leaves out ClientModified when it is unset.
+func (in UploadInput) MarshalJSON() ([]byte, error) {
+	type upload UploadInput
+	return json.Marshal(struct {
+		upload
+		ClientModified *time.Time `json:"client_modified,omitempty"`
+	}{upload(in), clientModified(in.ClientModified)})
+}
+
+// UploadOutput request output.
+type UploadOutput struct {
+	FileMetadata
+}
+
+// Upload a file smaller than 150MB, see UploadLarge for larger files.
+func (c *Files) Upload(ctx context.Context, in *UploadInput) (out *UploadOutput, err error) {
+	var hash *ContentHash
+	if in.VerifyContentHash {
+		if in, hash, err = hashUpload(in); err != nil {
+			return
+		}
+	}
+
+	res, err := c.download(ctx, "content", "/files/upload", in, in.Reader)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if err = json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return
+	}
+
+	if hash != nil {
+		if err = checkContentHash(in.Path, hash, out.ContentHash); err != nil {
+			out = nil
+		}
+	}
+	return
+}
+
+// hashUpload prepares an upload for verification. A seekable reader is hashed
+// up front so the hash can be sent for Dropbox to check too, while any other
+// reader is hashed as it is sent.
+func hashUpload(in *UploadInput) (*UploadInput, *ContentHash, error) {
+	upload := *in
+	hash := NewContentHash()
+
+	s, ok := in.Reader.(io.ReadSeeker)
+	if !ok {
+		upload.Reader = io.TeeReader(in.Reader, hash)
+		return &upload, hash, nil
+	}
+
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := io.Copy(hash, s); err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	upload.ContentHash = hash.String()
+	return &upload, hash, nil
+}
+
+// CommitInfo determines where and how an upload session is saved.
+type CommitInfo struct {
 	Path           string    `json:"path"`
 	Mode           WriteMode `json:"mode"`
 	AutoRename     bool      `json:"autorename"`
 	Mute           bool      `json:"mute"`
 	ClientModified time.Time `json:"client_modified,omitempty"`
-	Reader         io.Reader `json:"-"`
 }
 
-// UploadOutput request output.
-type UploadOutput struct {
-	Metadata
+// MarshalJSON leaves out ClientModified when it is unset.
+func (c CommitInfo) MarshalJSON() ([]byte, error) {
+	type commit CommitInfo
+	return json.Marshal(struct {
+		commit
+		ClientModified *time.Time `json:"client_modified,omitempty"`
+	}{commit(c), clientModified(c.ClientModified)})
 }
 
-// Upload a file smaller than 150MB.
-func (c *Files) Upload(ctx context.Context, in *UploadInput) (out *UploadOutput, err error) {
-	body, _, err := c.download(ctx, "content", "/files/upload", in, in.Reader)
+// clientModified returns t in the form Dropbox expects, UTC without
+// fractional seconds, or nil when it is unset.
+func clientModified(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC().Truncate(time.Second)
+	return &t
+}
+
+// commitInfo returns the commit options of the upload.
+func (in *UploadInput) commitInfo() CommitInfo {
+	return CommitInfo{
+		Path:           in.Path,
+		Mode:           in.Mode,
+		AutoRename:     in.AutoRename,
+		Mute:           in.Mute,
+		ClientModified: in.ClientModified,
+	}
+}
+
+// UploadSessionCursor identifies an upload session and the number of bytes
+// uploaded to it so far.
+type UploadSessionCursor struct {
+	SessionID string `json:"session_id"`
+	Offset    uint64 `json:"offset"`
+}
+
+// UploadSessionStartInput request input.
+type UploadSessionStartInput struct {
+	Close       bool      `json:"close"`
+	ContentHash string    `json:"content_hash,omitempty"` // of the data sent in this call
+	Reader      io.Reader `json:"-"`
+}
+
+// UploadSessionStartOutput request output.
+type UploadSessionStartOutput struct {
+	SessionID string `json:"session_id"`
+}
+
+// UploadSessionStart begins an upload session with the first chunk of data.
+// A session must be finished within a week of starting it.
+func (c *Files) UploadSessionStart(ctx context.Context, in *UploadSessionStartInput) (out *UploadSessionStartOutput, err error) {
+	res, err := c.download(ctx, "content", "/files/upload_session/start", in, uploadBody(in.Reader))
 	if err != nil {
 		return
 	}
-	defer body.Close()
+	defer res.Body.Close()
 
-	err = json.NewDecoder(body).Decode(&out)
+	err = json.NewDecoder(res.Body).Decode(&out)
 	return
 }
 
-// DownloadInput request input.
+// UploadSessionAppendInput request input.
+type UploadSessionAppendInput struct {
+	Cursor      UploadSessionCursor `json:"cursor"`
+	Close       bool                `json:"close"`
+	ContentHash string              `json:"content_hash,omitempty"` // of the data sent in this call
+	Reader      io.Reader           `json:"-"`
+}
+
+// UploadSessionAppend adds a chunk of data to an upload session at the
+// cursor's offset. Set Close on the last chunk when the session is finished
+// in a batch.
+func (c *Files) UploadSessionAppend(ctx context.Context, in *UploadSessionAppendInput) (err error) {
+	res, err := c.download(ctx, "content", "/files/upload_session/append_v2", in, uploadBody(in.Reader))
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	return
+}
+
+// UploadSessionFinishInput request input.
+type UploadSessionFinishInput struct {
+	Cursor      UploadSessionCursor `json:"cursor"`
+	Commit      CommitInfo          `json:"commit"`
+	ContentHash string              `json:"content_hash,omitempty"` // of the data sent in this call
+	Reader      io.Reader           `json:"-"`
+}
+
+// UploadSessionFinish saves an upload session as a file, after appending any
+// remaining data.
+func (c *Files) UploadSessionFinish(ctx context.Context, in *UploadSessionFinishInput) (out *UploadOutput, err error) {
+	res, err := c.download(ctx, "content", "/files/upload_session/finish", in, uploadBody(in.Reader))
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	err = json.NewDecoder(res.Body).Decode(&out)
+	return
+}
+
+// DefaultUploadChunkSize is the chunk size used by UploadLarge when none is
+// given. Chunks should be a multiple of 4MB and no larger than 150MB.
+const DefaultUploadChunkSize = 8 << 20
+
+// UploadLargeInput request input.
+type UploadLargeInput struct {
+	UploadInput
+	ChunkSize int // defaults to DefaultUploadChunkSize
+}
+
+// UploadLarge uploads a file of any size, sending the reader in chunks
+// through an upload session. Files which fit in a single chunk are sent with
+// Upload instead.
+//
+// Dropbox only checks the content hash of the data sent in each call of a
+// session, so when in.ContentHash is set every chunk is sent with its own
+// hash, and the hash of the whole file is checked before the last chunk is
+// sent. An *IntegrityError is returned, and nothing is saved, on a mismatch.
+func (c *Files) UploadLarge(ctx context.Context, in *UploadLargeInput) (out *UploadOutput, err error) {
+	size := in.ChunkSize
+	if size <= 0 {
+		size = DefaultUploadChunkSize
+	}
+	buf := make([]byte, size)
+
+	var hash *ContentHash
+	if in.VerifyContentHash || in.ContentHash != "" {
+		hash = NewContentHash()
+	}
+
+	r := uploadBody(in.Reader)
+	n, last, err := readChunk(r, buf)
+	if err != nil {
+		return
+	}
+
+	if last {
+		upload := in.UploadInput
+		upload.Reader = bytes.NewReader(buf[:n])
+		return c.Upload(ctx, &upload)
+	}
+
+	if hash != nil {
+		hash.Write(buf[:n])
+	}
+
+	start, err := c.UploadSessionStart(ctx, &UploadSessionStartInput{
+		ContentHash: chunkHash(hash, buf[:n]),
+		Reader:      bytes.NewReader(buf[:n]),
+	})
+	if err != nil {
+		return
+	}
+
+	cursor := UploadSessionCursor{SessionID: start.SessionID, Offset: uint64(n)}
+
+	for {
+		n, last, err = readChunk(r, buf)
+		if err != nil {
+			return
+		}
+
+		if hash != nil {
+			hash.Write(buf[:n])
+		}
+
+		if last {
+			if in.ContentHash != "" {
+				if err = checkContentHash(in.Path, hash, in.ContentHash); err != nil {
+					return
+				}
+			}
+
+			out, err = c.UploadSessionFinish(ctx, &UploadSessionFinishInput{
+				Cursor:      cursor,
+				Commit:      in.commitInfo(),
+				ContentHash: chunkHash(hash, buf[:n]),
+				Reader:      bytes.NewReader(buf[:n]),
+			})
+			if err == nil && in.VerifyContentHash {
+				if err = checkContentHash(in.Path, hash, out.ContentHash); err != nil {
+					out = nil
+				}
+			}
+			return
+		}
+
+		err = c.UploadSessionAppend(ctx, &UploadSessionAppendInput{
+			Cursor:      cursor,
+			ContentHash: chunkHash(hash, buf[:n]),
+			Reader:      bytes.NewReader(buf[:n]),
+		})
+		if err != nil {
+			return
+		}
+
+		cursor.Offset += uint64(n)
+	}
+}
+
+// chunkHash returns the content hash of a chunk of an upload session, or
+// nothing when the upload is not hashed.
+func chunkHash(hash *ContentHash, chunk []byte) string {
+	if hash == nil {
+		return ""
+	}
+
+	h := NewContentHash()
+	h.Write(chunk)
+	return h.String()
+}
+
+// readChunk fills buf from r, reporting whether r is exhausted.
+func readChunk(r io.Reader, buf []byte) (n int, last bool, err error) {
+	n, err = io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, true, nil
+	}
+	return n, false, err
+}
+
+// uploadBody returns an empty body for a nil reader, so that session
+// requests without data are still sent as application/octet-stream.
+func uploadBody(r io.Reader) io.Reader {
+	if r == nil {
+		return bytes.NewReader(nil)
+	}
+	return r
+}
+
+// DownloadInput request input. Set Offset and Length to download part of
+// the file. Set VerifyContentHash to have reading the whole Body return an
+// *IntegrityError instead of io.EOF if it does not match the file's content
+// hash.
 type DownloadInput struct {
-	Path string `json:"path"`
+	Path              string `json:"path"`
+	Offset            int64  `json:"-"` // first byte to download
+	Length            int64  `json:"-"` // bytes to download from Offset, zero for the rest of the file
+	VerifyContentHash bool   `json:"-"`
+}
+
+// setHeaders requests the byte range, if any.
+func (in *DownloadInput) setHeaders(h http.Header) {
+	switch {
+	case in.Length > 0:
+		h.Set("Range", fmt.Sprintf("bytes=%d-%d", in.Offset, in.Offset+in.Length-1))
+	case in.Offset > 0:
+		h.Set("Range", fmt.Sprintf("bytes=%d-", in.Offset))
+	}
+}
+
+// ContentRange is the byte range of the file served by a ranged download.
+type ContentRange struct {
+	Start int64 // first byte served
+	End   int64 // last byte served, inclusive
+	Size  int64 // size of the whole file, or -1 when unknown
+}
+
+// parseContentRange parses a Content-Range header such as "bytes 0-99/1234".
+func parseContentRange(s string) (*ContentRange, error) {
+	var r ContentRange
+	var size string
+	if _, err := fmt.Sscanf(s, "bytes %d-%d/%s", &r.Start, &r.End, &size); err != nil {
+		return nil, fmt.Errorf("dropbox: invalid Content-Range %q", s)
+	}
+
+	r.Size = -1
+	if size != "*" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("dropbox: invalid Content-Range %q", s)
+		}
+		r.Size = n
+	}
+	return &r, nil
 }
 
 // DownloadOutput request output.
 type DownloadOutput struct {
-	Body   io.ReadCloser
-	Length int64
+	Body     io.ReadCloser
+	Length   int64
+	Metadata *FileMetadata // metadata of the downloaded revision
+	Range    *ContentRange // range served, nil when the whole file was served
 }
 
 // Download a file.
 func (c *Files) Download(ctx context.Context, in *DownloadInput) (out *DownloadOutput, err error) {
-	body, l, err := c.download(ctx, "content", "/files/download", in, nil)
+	if in.VerifyContentHash && (in.Offset > 0 || in.Length > 0) {
+		return nil, fmt.Errorf("dropbox: cannot verify the content hash of part of %s", in.Path)
+	}
+
+	res, err := c.download(ctx, "content", "/files/download", in, nil)
 	if err != nil {
 		return
 	}
 
-	out = &DownloadOutput{body, l}
+	out = &DownloadOutput{Body: res.Body, Length: res.ContentLength}
+	if err = apiResult(res, &out.Metadata); err != nil {
+		out = nil
+		return
+	}
+
+	if res.StatusCode == http.StatusPartialContent {
+		if out.Range, err = parseContentRange(res.Header.Get("Content-Range")); err != nil {
+			res.Body.Close()
+			out = nil
+		}
+		return
+	}
+
+	if in.VerifyContentHash {
+		if out.Metadata == nil || out.Metadata.ContentHash == "" {
+			res.Body.Close()
+			return nil, fmt.Errorf("dropbox: no content hash returned for %s", in.Path)
+		}
+
+		out.Body = &verifyingReader{
+			ReadCloser: res.Body,
+			hash:       NewContentHash(),
+			path:       in.Path,
+			expected:   out.Metadata.ContentHash,
+		}
+	}
 	return
 }
 
+// RevisionChangedError is returned when a file changed since part of it was
+// downloaded, so the remainder cannot be appended.
+type RevisionChangedError struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// Error string.
+func (e *RevisionChangedError) Error() string {
+	return fmt.Sprintf("dropbox: %s changed from rev %s to %s", e.Path, e.Expected, e.Actual)
+}
+
+// ResumeDownloadInput request input.
+type ResumeDownloadInput struct {
+	Path string
+	Rev  string   // revision the partial download in File was made from
+	File *os.File // partial download, appended to from its current size
+}
+
+// ResumeDownloadOutput request output.
+type ResumeDownloadOutput struct {
+	Metadata *FileMetadata
+	Written  int64 // bytes appended to the file
+}
+
+// ResumeDownload completes an interrupted download by appending the rest of
+// the file, starting at the current size of in.File. A *RevisionChangedError
+// is returned, and nothing is written, if the file no longer has revision
+// in.Rev.
+func (c *Files) ResumeDownload(ctx context.Context, in *ResumeDownloadInput) (out *ResumeDownloadOutput, err error) {
+	if in.Rev == "" {
+		return nil, fmt.Errorf("dropbox: resuming %s requires the rev of the partial download", in.Path)
+	}
+
+	fi, err := in.File.Stat()
+	if err != nil {
+		return
+	}
+
+	dl, err := c.Download(ctx, &DownloadInput{Path: in.Path, Offset: fi.Size()})
+	if e, ok := err.(*Error); ok && e.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return c.resumeComplete(ctx, in, fi.Size())
+	}
+	if err != nil {
+		return
+	}
+	defer dl.Body.Close()
+
+	if dl.Metadata == nil {
+		return nil, fmt.Errorf("dropbox: download of %s has no metadata to check its rev against", in.Path)
+	}
+	if dl.Metadata.Rev != in.Rev {
+		return nil, &RevisionChangedError{Path: in.Path, Expected: in.Rev, Actual: dl.Metadata.Rev}
+	}
+
+	if fi.Size() > 0 && dl.Range == nil {
+		return nil, fmt.Errorf("dropbox: range request for %s was not honored", in.Path)
+	}
+
+	if _, err = in.File.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	n, err := io.Copy(in.File, dl.Body)
+	if err != nil {
+		return
+	}
+
+	return &ResumeDownloadOutput{Metadata: dl.Metadata, Written: n}, nil
+}
+
+// resumeComplete handles a download which has nothing left to resume, making
+// sure the local file is the whole of the expected revision.
+func (c *Files) resumeComplete(ctx context.Context, in *ResumeDownloadInput, size int64) (*ResumeDownloadOutput, error) {
+	out, err := c.GetMetadata(ctx, &GetMetadataInput{Path: in.Path})
+	if err != nil {
+		return nil, err
+	}
+
+	meta, ok := out.Metadata.(*FileMetadata)
+	if !ok {
+		return nil, fmt.Errorf("dropbox: %s is a %s, not a file", in.Path, out.Tag())
+	}
+
+	if meta.Rev != in.Rev {
+		return nil, &RevisionChangedError{Path: in.Path, Expected: in.Rev, Actual: meta.Rev}
+	}
+
+	if uint64(size) != meta.Size {
+		return nil, fmt.Errorf("dropbox: partial download of %s is larger than the file", in.Path)
+	}
+
+	return &ResumeDownloadOutput{Metadata: meta}, nil
+}
+
 // ThumbnailFormat determines the format of the thumbnail.
 type ThumbnailFormat string
 
@@ -436,19 +1266,23 @@ type GetThumbnailInput struct {
 
 // GetThumbnailOutput request output.
 type GetThumbnailOutput struct {
-	Body   io.ReadCloser
-	Length int64
+	Body     io.ReadCloser
+	Length   int64
+	Metadata *FileMetadata // metadata of the file the thumbnail was made from
 }
 
 // GetThumbnail a thumbnail for a file. Currently thumbnails are only generated for the
 // files with the following extensions: png, jpeg, png, tiff, tif, gif and bmp.
 func (c *Files) GetThumbnail(ctx context.Context, in *GetThumbnailInput) (out *GetThumbnailOutput, err error) {
-	body, l, err := c.download(ctx, "content", "/files/get_thumbnail", in, nil)
+	res, err := c.download(ctx, "content", "/files/get_thumbnail", in, nil)
 	if err != nil {
 		return
 	}
 
-	out = &GetThumbnailOutput{body, l}
+	out = &GetThumbnailOutput{Body: res.Body, Length: res.ContentLength}
+	if err = apiResult(res, &out.Metadata); err != nil {
+		out = nil
+	}
 	return
 }
 
@@ -459,20 +1293,24 @@ type GetPreviewInput struct {
 
 // GetPreviewOutput request output.
 type GetPreviewOutput struct {
-	Body   io.ReadCloser
-	Length int64
+	Body     io.ReadCloser
+	Length   int64
+	Metadata *FileMetadata // metadata of the file the preview was made from
 }
 
 // GetPreview a preview for a file. Currently previews are only generated for the
 // files with the following extensions: .doc, .docx, .docm, .ppt, .pps, .ppsx,
 // .ppsm, .pptx, .pptm, .xls, .xlsx, .xlsm, .rtf
 func (c *Files) GetPreview(ctx context.Context, in *GetPreviewInput) (out *GetPreviewOutput, err error) {
-	body, l, err := c.download(ctx, "content", "/files/get_preview", in, nil)
+	res, err := c.download(ctx, "content", "/files/get_preview", in, nil)
 	if err != nil {
 		return
 	}
 
-	out = &GetPreviewOutput{body, l}
+	out = &GetPreviewOutput{Body: res.Body, Length: res.ContentLength}
+	if err = apiResult(res, &out.Metadata); err != nil {
+		out = nil
+	}
 	return
 }
 
@@ -484,8 +1322,8 @@ type ListRevisionsInput struct {
 
 // ListRevisionsOutput request output.
 type ListRevisionsOutput struct {
-	IsDeleted bool
-	Entries   []*Metadata
+	IsDeleted bool            `json:"is_deleted"`
+	Entries   []*FileMetadata `json:"entries"`
 }
 
 // ListRevisions gets the revisions of the specified file.
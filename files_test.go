@@ -2,15 +2,22 @@ package dropbox
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFiles_Upload(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	file, err := os.Open("Readme.md")
 	assert.NoError(t, err, "error opening file")
@@ -28,9 +35,9 @@ func TestFiles_Upload(t *testing.T) {
 }
 
 func TestFiles_Download(t *testing.T) {
-	c := client()
+	c := client(t)
 
-	out, err := c.Files.Download(ctx, &DownloadInput{"/Readme.md"})
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/Readme.md"})
 
 	assert.NoError(t, err, "error downloading")
 	defer out.Body.Close()
@@ -46,34 +53,54 @@ func TestFiles_Download(t *testing.T) {
 	assert.NoError(t, err, "error reading local")
 
 	assert.Equal(t, local, remote, "Readme.md mismatch")
+	assert.Equal(t, "/readme.md", out.Metadata.PathLower)
+	assert.NotEmpty(t, out.Metadata.Rev)
+}
+
+func TestFiles_Download_metadata(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/files/download", r.URL.Path)
+		w.Header().Set("Dropbox-API-Result", `{"name": "hello.txt", "path_lower": "/hello.txt", "rev": "a1c10ce0dd78", "size": 5, "client_modified": "2015-05-12T15:50:38Z"}`)
+		io.WriteString(w, "hello")
+	})
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	defer out.Body.Close()
+
+	assert.Equal(t, "a1c10ce0dd78", out.Metadata.Rev)
+	assert.Equal(t, uint64(5), out.Metadata.Size)
+	assert.Equal(t, 2015, out.Metadata.ClientModified.Year())
 }
 
 func TestFiles_GetMetadata(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.GetMetadata(ctx, &GetMetadataInput{
 		Path: "/Readme.md",
 	})
 	assert.NoError(t, err)
-	assert.Equal(t, "file", out.Tag)
+	assert.IsType(t, &FileMetadata{}, out.Metadata)
+	assert.Equal(t, "file", out.Tag())
 }
 
 func TestFiles_GetMetadataWithMediaInfo(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.GetMetadata(ctx, &GetMetadataInput{
 		Path:             "/IMG_0001.jpg",
 		IncludeMediaInfo: true,
 	})
-	assert.NoError(t, err)
-	assert.Equal(t, "file", out.Tag)
-	assert.Equal(t, "photo", out.MediaInfo.Metadata.Tag)
-	assert.NotNil(t, out.MediaInfo.Metadata.Dimensions)
+	require.NoError(t, err)
+	require.IsType(t, &FileMetadata{}, out.Metadata)
+	file := out.Metadata.(*FileMetadata)
+	assert.Equal(t, "photo", file.MediaInfo.Metadata.Tag)
+	assert.NotNil(t, file.MediaInfo.Metadata.Dimensions)
 }
 
 func TestFiles_ListFolder(t *testing.T) {
 	t.Parallel()
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.ListFolder(ctx, &ListFolderInput{
 		Path: "/list",
@@ -86,7 +113,7 @@ func TestFiles_ListFolder(t *testing.T) {
 
 func TestFiles_ListFolder_root(t *testing.T) {
 	t.Parallel()
-	c := client()
+	c := client(t)
 
 	_, err := c.Files.ListFolder(ctx, &ListFolderInput{
 		Path: "/",
@@ -96,7 +123,7 @@ func TestFiles_ListFolder_root(t *testing.T) {
 }
 
 func TestFiles_Search(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.Search(ctx, &SearchInput{
 		Path:  "/",
@@ -108,14 +135,14 @@ func TestFiles_Search(t *testing.T) {
 }
 
 func TestFiles_Delete(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.Delete(ctx, &DeleteInput{
 		Path: "/Readme.md",
 	})
 
 	assert.NoError(t, err)
-	assert.Equal(t, "/readme.md", out.PathLower)
+	assert.Equal(t, "/readme.md", out.Entry().PathLower)
 }
 
 // A gray, 64 by 64 px PNG
@@ -140,7 +167,7 @@ var grayPng = []byte{
 }
 
 func TestFiles_GetThumbnail(t *testing.T) {
-	c := client()
+	c := client(t)
 	// REVIEW(bg): This feels a bit sloppy...
 	{
 		buf := bytes.NewBuffer(grayPng)
@@ -171,7 +198,7 @@ func TestFiles_GetThumbnail(t *testing.T) {
 }
 
 func TestFiles_GetPreview(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.GetPreview(ctx, &GetPreviewInput{"/sample.ppt"})
 	defer out.Body.Close()
@@ -187,7 +214,7 @@ func TestFiles_GetPreview(t *testing.T) {
 }
 
 func TestFiles_ListRevisions(t *testing.T) {
-	c := client()
+	c := client(t)
 
 	out, err := c.Files.ListRevisions(ctx, &ListRevisionsInput{Path: "/sample.ppt"})
 
@@ -195,3 +222,396 @@ func TestFiles_ListRevisions(t *testing.T) {
 	assert.NotEmpty(t, out.Entries)
 	assert.False(t, out.IsDeleted)
 }
+
+// sessionServer implements the upload session routes, assembling the data of
+// each session in memory.
+func sessionServer(t *testing.T, sessions map[string][]byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var arg struct {
+			Cursor UploadSessionCursor `json:"cursor"`
+			Commit CommitInfo          `json:"commit"`
+			Path   string              `json:"path"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(r.Header.Get("Dropbox-API-Arg")), &arg))
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/files/upload":
+			fmt.Fprintf(w, `{".tag": "file", "path_lower": %q, "size": %d}`, arg.Path, len(data))
+			return
+		case "/2/files/upload_session/start":
+			id := fmt.Sprintf("session-%d", len(sessions)+1)
+			sessions[id] = data
+			fmt.Fprintf(w, `{"session_id": %q}`, id)
+			return
+		}
+
+		session := sessions[arg.Cursor.SessionID]
+		assert.Equal(t, uint64(len(session)), arg.Cursor.Offset, "offset should match data uploaded so far")
+		sessions[arg.Cursor.SessionID] = append(session, data...)
+
+		switch r.URL.Path {
+		case "/2/files/upload_session/append_v2":
+			io.WriteString(w, "null")
+		case "/2/files/upload_session/finish":
+			fmt.Fprintf(w, `{".tag": "file", "path_lower": %q, "size": %d}`, arg.Commit.Path, len(sessions[arg.Cursor.SessionID]))
+		}
+	}
+}
+
+func TestFiles_UploadLarge(t *testing.T) {
+	sessions := map[string][]byte{}
+	c := testClient(t, sessionServer(t, sessions))
+
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	out, err := c.Files.UploadLarge(ctx, &UploadLargeInput{
+		UploadInput: UploadInput{
+			Path:   "/large.txt",
+			Mode:   WriteModeOverwrite,
+			Reader: bytes.NewReader(data),
+		},
+		ChunkSize: 100,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/large.txt", out.PathLower)
+	assert.Equal(t, uint64(len(data)), out.Size)
+	assert.Equal(t, data, sessions["session-1"])
+}
+
+func TestFiles_UploadLarge_small(t *testing.T) {
+	sessions := map[string][]byte{}
+	c := testClient(t, sessionServer(t, sessions))
+
+	out, err := c.Files.UploadLarge(ctx, &UploadLargeInput{
+		UploadInput: UploadInput{
+			Path:   "/small.txt",
+			Reader: bytes.NewReader([]byte("hello")),
+		},
+		ChunkSize: 100,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/small.txt", out.PathLower)
+	assert.Empty(t, sessions, "small files should not start a session")
+}
+
+func TestFiles_UploadLarge_contentHash(t *testing.T) {
+	sessions := map[string][]byte{}
+	next := sessionServer(t, sessions)
+
+	hashes := map[string]int{}
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var arg struct {
+			ContentHash string `json:"content_hash"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(r.Header.Get("Dropbox-API-Arg")), &arg))
+
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		hash, err := ContentHashReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, hash, arg.ContentHash, "%s should carry the hash of its chunk", r.URL.Path)
+		hashes[r.URL.Path]++
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		next(w, r)
+	})
+
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	hash, err := ContentHashReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	_, err = c.Files.UploadLarge(ctx, &UploadLargeInput{
+		UploadInput: UploadInput{
+			Path:        "/large.txt",
+			ContentHash: hash,
+			Reader:      bytes.NewReader(data),
+		},
+		ChunkSize: 100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"/2/files/upload_session/start":     1,
+		"/2/files/upload_session/append_v2": 1,
+		"/2/files/upload_session/finish":    1,
+	}, hashes)
+
+	hashes = map[string]int{}
+	_, err = c.Files.UploadLarge(ctx, &UploadLargeInput{
+		UploadInput: UploadInput{
+			Path:        "/large.txt",
+			ContentHash: hash,
+			Reader:      bytes.NewReader(append(data, '!')),
+		},
+		ChunkSize: 100,
+	})
+	require.IsType(t, &IntegrityError{}, err)
+	assert.Zero(t, hashes["/2/files/upload_session/finish"], "a mismatched file should not be committed")
+}
+
+func TestUploadInput_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(&UploadInput{Path: "/a.txt", Mode: WriteModeAdd})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path": "/a.txt", "mode": "add", "autorename": false, "mute": false}`, string(b))
+
+	b, err = json.Marshal(&UploadSessionFinishInput{Commit: CommitInfo{Path: "/a.txt", Mode: WriteModeAdd}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"cursor": {"session_id": "", "offset": 0}, "commit": {"path": "/a.txt", "mode": "add", "autorename": false, "mute": false}}`, string(b))
+
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("", 3600))
+	b, err = json.Marshal(&UploadInput{Path: "/a.txt", ClientModified: modified})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"client_modified":"2020-01-02T02:04:05Z"`)
+
+	b, err = json.Marshal(UploadSessionFinishBatchArg{Commit: CommitInfo{Path: "/a.txt", ClientModified: modified}})
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"client_modified":"2020-01-02T02:04:05Z"`)
+}
+
+func TestFiles_UploadLarge_nilReader(t *testing.T) {
+	sessions := map[string][]byte{}
+	c := testClient(t, sessionServer(t, sessions))
+
+	out, err := c.Files.UploadLarge(ctx, &UploadLargeInput{
+		UploadInput: UploadInput{Path: "/empty.txt"},
+		ChunkSize:   100,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/empty.txt", out.PathLower)
+	assert.Equal(t, uint64(0), out.Size)
+}
+
+// rangeServer serves content for /hello.txt at the given rev, honoring
+// Range headers.
+func rangeServer(t *testing.T, content, rev string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Dropbox-API-Result", fmt.Sprintf(`{"name": "hello.txt", "path_lower": "/hello.txt", "rev": %q, "size": %d}`, rev, len(content)))
+		http.ServeContent(w, r, "hello.txt", time.Time{}, strings.NewReader(content))
+	}
+}
+
+func TestFiles_Download_range(t *testing.T) {
+	c := testClient(t, rangeServer(t, "hello world", "a1"))
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt", Offset: 6, Length: 3})
+	require.NoError(t, err)
+	defer out.Body.Close()
+
+	b, err := ioutil.ReadAll(out.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "wor", string(b))
+	assert.Equal(t, &ContentRange{Start: 6, End: 8, Size: 11}, out.Range)
+}
+
+func TestFiles_ResumeDownload(t *testing.T) {
+	c := testClient(t, rangeServer(t, "hello world", "a1"))
+
+	f, err := ioutil.TempFile(t.TempDir(), "hello")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString("hello")
+	require.NoError(t, err)
+
+	out, err := c.Files.ResumeDownload(ctx, &ResumeDownloadInput{Path: "/hello.txt", Rev: "a1", File: f})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), out.Written)
+	assert.Equal(t, "a1", out.Metadata.Rev)
+
+	b, err := ioutil.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+}
+
+func TestFiles_ResumeDownload_revisionChanged(t *testing.T) {
+	c := testClient(t, rangeServer(t, "HELLO WORLD", "b2"))
+
+	f, err := ioutil.TempFile(t.TempDir(), "hello")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString("hello")
+	require.NoError(t, err)
+
+	_, err = c.Files.ResumeDownload(ctx, &ResumeDownloadInput{Path: "/hello.txt", Rev: "a1", File: f})
+	require.IsType(t, &RevisionChangedError{}, err)
+	assert.Equal(t, "b2", err.(*RevisionChangedError).Actual)
+
+	b, err := ioutil.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b), "nothing should be appended")
+}
+
+func TestFiles_ResumeDownload_noMetadata(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 5-9/10")
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, "WORLD")
+	})
+
+	f, err := ioutil.TempFile(t.TempDir(), "hello")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString("hello")
+	require.NoError(t, err)
+
+	_, err = c.Files.ResumeDownload(ctx, &ResumeDownloadInput{Path: "/hello.txt", Rev: "a1", File: f})
+	require.Error(t, err)
+
+	b, err := ioutil.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b), "nothing should be appended")
+}
+
+func TestFiles_ListFolderLongpoll(t *testing.T) {
+	var polls []time.Time
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/files/list_folder/longpoll", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"), "longpoll should not be authorized")
+
+		var in ListFolderLongpollInput
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, "cursor", in.Cursor)
+		assert.Equal(t, uint64(MinLongpollTimeout), in.Timeout)
+
+		polls = append(polls, time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"changes": true, "backoff": 1}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		out, err := c.Files.ListFolderLongpoll(ctx, &ListFolderLongpollInput{Cursor: "cursor", Timeout: 5})
+		require.NoError(t, err)
+		assert.True(t, out.Changes)
+		assert.Equal(t, uint64(1), out.Backoff)
+	}
+
+	require.Len(t, polls, 2)
+	assert.True(t, polls[1].Sub(polls[0]) >= time.Second, "second poll should wait for the backoff")
+}
+
+func TestFiles_GetLatestCursor(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/files/list_folder/get_latest_cursor", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"cursor": "ZtkX9_EHj3x7PMkVuFIhwKYXEpwpLwyxp9vMKomUhllil9q7eWiAu"}`)
+	})
+
+	out, err := c.Files.GetLatestCursor(ctx, &ListFolderInput{Path: "/"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, out.Cursor)
+}
+
+func TestFiles_ListFolder_entries(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"cursor": "c", "has_more": false, "entries": [
+			{".tag": "file", "name": "a.txt", "path_lower": "/a.txt", "id": "id:a", "rev": "1", "size": 3},
+			{".tag": "folder", "name": "b", "path_lower": "/b", "id": "id:b"},
+			{".tag": "deleted", "name": "c.txt", "path_lower": "/c.txt"}
+		]}`)
+	})
+
+	out, err := c.Files.ListFolder(ctx, &ListFolderInput{Path: "/", IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, out.Entries, 3)
+
+	for _, entry := range out.Entries {
+		switch m := entry.(type) {
+		case *FileMetadata:
+			assert.Equal(t, "/a.txt", m.PathLower)
+			assert.Equal(t, uint64(3), m.Size)
+		case *FolderMetadata:
+			assert.Equal(t, "id:b", m.ID)
+		case *DeletedMetadata:
+			assert.Equal(t, "c.txt", m.Name)
+		default:
+			t.Errorf("unexpected entry %T", m)
+		}
+	}
+
+	b, err := json.Marshal(out.Entries[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{".tag": "folder", "name": "b", "path_lower": "/b", "id": "id:b"}`, string(b))
+}
+
+func TestFiles_GetMetadata_fields(t *testing.T) {
+	var in map[string]interface{}
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			".tag": "file",
+			"name": "Prime_Numbers.txt",
+			"id": "id:a4ayc_80_OEAAAAAAAAAXw",
+			"client_modified": "2015-05-12T15:50:38Z",
+			"server_modified": "2015-05-12T15:50:38Z",
+			"rev": "a1c10ce0dd78",
+			"size": 7212,
+			"path_lower": "/homework/math/prime_numbers.txt",
+			"path_display": "/Homework/math/Prime_Numbers.txt",
+			"sharing_info": {"read_only": true, "parent_shared_folder_id": "84528192421", "modified_by": "dbid:AAH4f99T0taONIb-OurWxbNQ6ywGRopQngc"},
+			"is_downloadable": true,
+			"property_groups": [{"template_id": "ptid:1a5n2i6d3OYEAAAAAAAAAYa", "fields": [{"name": "Security Policy", "value": "Confidential"}]}],
+			"has_explicit_shared_members": false,
+			"content_hash": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			"file_lock_info": {"is_lockholder": true, "lockholder_name": "Imaginary User", "created": "2015-05-12T15:50:38Z"}
+		}`)
+	})
+
+	out, err := c.Files.GetMetadata(ctx, &GetMetadataInput{
+		Path:                            "/Homework/math/Prime_Numbers.txt",
+		IncludeHasExplicitSharedMembers: true,
+		IncludePropertyGroups:           FilterSomeTemplates("ptid:1a5n2i6d3OYEAAAAAAAAAYa"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, true, in["include_has_explicit_shared_members"])
+	assert.Equal(t, map[string]interface{}{
+		".tag":        "filter_some",
+		"filter_some": []interface{}{"ptid:1a5n2i6d3OYEAAAAAAAAAYa"},
+	}, in["include_property_groups"])
+
+	file := out.Metadata.(*FileMetadata)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", file.ContentHash)
+	assert.True(t, file.IsDownloadable)
+	assert.Equal(t, "84528192421", file.SharingInfo.ParentSharedFolderID)
+	assert.Equal(t, "Confidential", file.PropertyGroups[0].Fields[0].Value)
+	assert.True(t, file.FileLockInfo.IsLockholder)
+	assert.Equal(t, "Imaginary User", file.FileLockInfo.LockholderName)
+}
+
+func TestFiles_ListFolder_folderSharingInfo(t *testing.T) {
+	var in map[string]interface{}
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"cursor": "c", "has_more": false, "entries": [{
+			".tag": "folder",
+			"name": "math",
+			"id": "id:a4ayc_80_OEAAAAAAAAAXz",
+			"path_lower": "/homework/math",
+			"sharing_info": {"read_only": false, "parent_shared_folder_id": "84528192421", "shared_folder_id": "84528192422", "traverse_only": false, "no_access": false}
+		}]}`)
+	})
+
+	mounted := false
+	out, err := c.Files.ListFolder(ctx, &ListFolderInput{
+		Path:                  "/Homework",
+		IncludeMountedFolders: &mounted,
+		Limit:                 100,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, false, in["include_mounted_folders"])
+	assert.NotContains(t, in, "include_non_downloadable_files", "unset flags should use the Dropbox default")
+	assert.Equal(t, float64(100), in["limit"])
+
+	folder := out.Entries[0].(*FolderMetadata)
+	assert.Equal(t, "84528192422", folder.SharingInfo.SharedFolderID)
+	assert.Equal(t, "84528192421", folder.SharingInfo.ParentSharedFolderID)
+}
@@ -0,0 +1,369 @@
+package dropbox
+
+import (
+	"context"
+)
+
+// Iterator pages through the results of a cursor-based list endpoint, such
+// as ListFolder and ListFolderContinue, returning one item at a time.
+//
+//	it := client.Files.ListFolderIterator(&dropbox.ListFolderInput{Path: "/photos"})
+//	for it.Next(ctx) {
+//		fmt.Println(it.Value().Entry().Name)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	first func(ctx context.Context) (*page[T], error)
+	next  func(ctx context.Context, cursor string) (*page[T], error)
+
+	cursor  string
+	items   []T
+	value   T
+	started bool
+	hasMore bool
+	err     error
+}
+
+// page of items and the cursor for the next page.
+type page[T any] struct {
+	items   []T
+	cursor  string
+	hasMore bool
+}
+
+// newIterator returns an iterator which calls first for the first page and
+// next for each page after it.
+func newIterator[T any](
+	first func(ctx context.Context) (*page[T], error),
+	next func(ctx context.Context, cursor string) (*page[T], error),
+) *Iterator[T] {
+	return &Iterator[T]{first: first, next: next}
+}
+
+// NewIterator returns an iterator which calls first for the first page and
+// next, with the cursor of the last page, for each page after it. It lets
+// other implementations of the API interfaces, such as mocks, return
+// iterators.
+func NewIterator[T any](
+	first func(ctx context.Context) (items []T, cursor string, hasMore bool, err error),
+	next func(ctx context.Context, cursor string) (items []T, nextCursor string, hasMore bool, err error),
+) *Iterator[T] {
+	return newIterator(
+		func(ctx context.Context) (*page[T], error) {
+			items, cursor, hasMore, err := first(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return &page[T]{items, cursor, hasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[T], error) {
+			items, cursor, hasMore, err := next(ctx, cursor)
+			if err != nil {
+				return nil, err
+			}
+			return &page[T]{items, cursor, hasMore}, nil
+		},
+	)
+}
+
+// From makes the iterator resume from a cursor returned by Cursor, rather
+// than start from the first page. It must be called before Next.
+func (it *Iterator[T]) From(cursor string) *Iterator[T] {
+	it.cursor = cursor
+	return it
+}
+
+// Next advances to the next item, fetching another page when needed. It
+// returns false when there are no more items, the context is cancelled or
+// an error occurred.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.err = ctx.Err(); it.err != nil {
+		return false
+	}
+
+	for len(it.items) == 0 {
+		if it.started && !it.hasMore {
+			return false
+		}
+
+		var p *page[T]
+		if it.cursor == "" {
+			p, it.err = it.first(ctx)
+		} else {
+			p, it.err = it.next(ctx, it.cursor)
+		}
+		if it.err != nil {
+			return false
+		}
+
+		it.started = true
+		it.items = p.items
+		it.cursor = p.cursor
+		it.hasMore = p.hasMore
+	}
+
+	it.value, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Value returns the current item.
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Cursor returns the cursor of the last page fetched. Resuming from it skips
+// any items of that page which Next has not yet returned, see PageDone.
+func (it *Iterator[T]) Cursor() string {
+	return it.cursor
+}
+
+// PageDone reports whether every item of the last page fetched has been
+// returned, so that resuming from Cursor neither skips nor repeats items.
+func (it *Iterator[T]) PageDone() bool {
+	return len(it.items) == 0
+}
+
+// ListFolderIterator returns an iterator over the entries of a folder.
+func (c *Files) ListFolderIterator(in *ListFolderInput) *Iterator[Metadata] {
+	return newIterator(
+		func(ctx context.Context) (*page[Metadata], error) {
+			out, err := c.ListFolder(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[Metadata]{out.Entries, out.Cursor, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[Metadata], error) {
+			out, err := c.ListFolderContinue(ctx, &ListFolderContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[Metadata]{out.Entries, out.Cursor, out.HasMore}, nil
+		},
+	)
+}
+
+// ListSharedFoldersIterator returns an iterator over the shared folders the
+// current user has access to.
+func (c *Sharing) ListSharedFoldersIterator(in *ListSharedFolderInput) *Iterator[SharedFolderMetadata] {
+	// The cursor is absent from the last page.
+	return newIterator(
+		func(ctx context.Context) (*page[SharedFolderMetadata], error) {
+			out, err := c.ListSharedFolders(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[SharedFolderMetadata]{out.Entries, out.Cursor, out.Cursor != ""}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[SharedFolderMetadata], error) {
+			out, err := c.ListSharedFoldersContinue(ctx, &ListSharedFolderContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[SharedFolderMetadata]{out.Entries, out.Cursor, out.Cursor != ""}, nil
+		},
+	)
+}
+
+// SharedMember is a member of a shared file or folder returned by the member
+// iterators. Exactly one of the fields is set.
+type SharedMember struct {
+	User    *UserMembershipInfo
+	Group   *GroupMembershipInfo
+	Invitee *InviteeMembershipInfo
+}
+
+// sharedMembers flattens a page of members into users, then groups, then
+// invitees. The cursor is absent from the last page.
+func sharedMembers(out *ListSharedMembersOutput) *page[SharedMember] {
+	p := &page[SharedMember]{cursor: out.Cursor, hasMore: out.Cursor != ""}
+	for i := range out.Users {
+		p.items = append(p.items, SharedMember{User: &out.Users[i]})
+	}
+	for i := range out.Groups {
+		p.items = append(p.items, SharedMember{Group: &out.Groups[i]})
+	}
+	for i := range out.Invitees {
+		p.items = append(p.items, SharedMember{Invitee: &out.Invitees[i]})
+	}
+	return p
+}
+
+// ListSharedFileMembersIterator returns an iterator over the members of a
+// shared file.
+func (c *Sharing) ListSharedFileMembersIterator(in *ListSharedFileMembersInput) *Iterator[SharedMember] {
+	return newIterator(
+		func(ctx context.Context) (*page[SharedMember], error) {
+			out, err := c.ListSharedFileMembers(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return sharedMembers(out), nil
+		},
+		func(ctx context.Context, cursor string) (*page[SharedMember], error) {
+			out, err := c.ListSharedFileMembersContinue(ctx, &ListSharedMembersContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return sharedMembers(out), nil
+		},
+	)
+}
+
+// ListSharedFolderMembersIterator returns an iterator over the members of a
+// shared folder.
+func (c *Sharing) ListSharedFolderMembersIterator(in *ListSharedFolderMembersInput) *Iterator[SharedMember] {
+	return newIterator(
+		func(ctx context.Context) (*page[SharedMember], error) {
+			out, err := c.ListSharedFolderMembers(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return sharedMembers(out), nil
+		},
+		func(ctx context.Context, cursor string) (*page[SharedMember], error) {
+			out, err := c.ListSharedFolderMembersContinue(ctx, &ListSharedMembersContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return sharedMembers(out), nil
+		},
+	)
+}
+
+// ListDocsIterator returns an iterator over the IDs of the documents in a
+// user's Dropbox Paper.
+func (c *Paper) ListDocsIterator(in *PaperDocsListInput) *Iterator[string] {
+	return newIterator(
+		func(ctx context.Context) (*page[string], error) {
+			out, err := c.ListDocs(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[string]{out.DocIDs, out.Cursor.Value, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[string], error) {
+			out, err := c.ListDocsContinue(ctx, &PaperDocsListContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[string]{out.DocIDs, out.Cursor.Value, out.HasMore}, nil
+		},
+	)
+}
+
+// ListMembersIterator returns an iterator over the members of the team.
+func (c *Team) ListMembersIterator(in *ListTeamMembersInput) *Iterator[*TeamMember] {
+	return newIterator(
+		func(ctx context.Context) (*page[*TeamMember], error) {
+			out, err := c.ListMembers(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[*TeamMember]{out.Members, out.Cursor, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[*TeamMember], error) {
+			out, err := c.ListMembersContinue(ctx, &ListTeamMembersContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[*TeamMember]{out.Members, out.Cursor, out.HasMore}, nil
+		},
+	)
+}
+
+// ListGroupsIterator returns an iterator over the groups on the team.
+func (c *Team) ListGroupsIterator(in *ListGroupsInput) *Iterator[*GroupSummary] {
+	return newIterator(
+		func(ctx context.Context) (*page[*GroupSummary], error) {
+			out, err := c.ListGroups(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[*GroupSummary]{out.Groups, out.Cursor, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[*GroupSummary], error) {
+			out, err := c.ListGroupsContinue(ctx, &ListGroupsContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[*GroupSummary]{out.Groups, out.Cursor, out.HasMore}, nil
+		},
+	)
+}
+
+// ListGroupMembersIterator returns an iterator over the members of a group.
+func (c *Team) ListGroupMembersIterator(in *ListGroupMembersInput) *Iterator[*GroupMember] {
+	return newIterator(
+		func(ctx context.Context) (*page[*GroupMember], error) {
+			out, err := c.ListGroupMembers(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[*GroupMember]{out.Members, out.Cursor, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[*GroupMember], error) {
+			out, err := c.ListGroupMembersContinue(ctx, &ListGroupMembersContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[*GroupMember]{out.Members, out.Cursor, out.HasMore}, nil
+		},
+	)
+}
+
+// ListNamespacesIterator returns an iterator over the namespaces on the team.
+func (c *Team) ListNamespacesIterator(in *ListNamespacesInput) *Iterator[*NamespaceMetadata] {
+	return newIterator(
+		func(ctx context.Context) (*page[*NamespaceMetadata], error) {
+			out, err := c.ListNamespaces(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[*NamespaceMetadata]{out.Namespaces, out.Cursor, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[*NamespaceMetadata], error) {
+			out, err := c.ListNamespacesContinue(ctx, &ListNamespacesContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[*NamespaceMetadata]{out.Namespaces, out.Cursor, out.HasMore}, nil
+		},
+	)
+}
+
+// Events returns an iterator over the team activity events matching in.
+// Requests are made through the team client, so rate limited pages are
+// retried according to its Config.Retry policy.
+func (c *Team) Events(in *GetTeamEventsInput) *Iterator[*TeamEvent] {
+	if in == nil {
+		in = &GetTeamEventsInput{}
+	}
+	return newIterator(
+		func(ctx context.Context) (*page[*TeamEvent], error) {
+			out, err := c.GetEvents(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return &page[*TeamEvent]{out.Events, out.Cursor, out.HasMore}, nil
+		},
+		func(ctx context.Context, cursor string) (*page[*TeamEvent], error) {
+			out, err := c.GetEventsContinue(ctx, &GetTeamEventsContinueInput{Cursor: cursor})
+			if err != nil {
+				return nil, err
+			}
+			return &page[*TeamEvent]{out.Events, out.Cursor, out.HasMore}, nil
+		},
+	)
+}
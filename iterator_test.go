@@ -0,0 +1,141 @@
+package dropbox
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listFolderServer serves two pages of a folder listing.
+func listFolderServer(t *testing.T, requests *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		*requests = append(*requests, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/2/files/list_folder":
+			io.WriteString(w, `{"entries": [{".tag": "file", "name": "a.txt"}, {".tag": "folder", "name": "b"}], "cursor": "c1", "has_more": true}`)
+		case strings.Contains(string(b), `"c1"`):
+			io.WriteString(w, `{"entries": [{".tag": "file", "name": "c.txt"}], "cursor": "c2", "has_more": false}`)
+		default:
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{"error_summary": "reset/..", "error": {".tag": "reset"}}`)
+		}
+	}
+}
+
+func TestIterator_ListFolder(t *testing.T) {
+	var requests []string
+	c := testClient(t, listFolderServer(t, &requests))
+
+	it := c.Files.ListFolderIterator(&ListFolderInput{Path: "/"})
+
+	var names []string
+	for it.Next(ctx) {
+		names = append(names, it.Value().Entry().Name)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"a.txt", "b", "c.txt"}, names)
+	assert.Equal(t, "c2", it.Cursor())
+	assert.True(t, it.PageDone())
+	assert.Equal(t, []string{"/2/files/list_folder", "/2/files/list_folder/continue"}, requests)
+	assert.False(t, it.Next(ctx), "a finished iterator should stay finished")
+}
+
+func TestIterator_From(t *testing.T) {
+	var requests []string
+	c := testClient(t, listFolderServer(t, &requests))
+
+	it := c.Files.ListFolderIterator(&ListFolderInput{Path: "/"}).From("c1")
+	require.True(t, it.Next(ctx))
+	assert.Equal(t, "c.txt", it.Value().Entry().Name)
+	assert.False(t, it.Next(ctx))
+	assert.Equal(t, []string{"/2/files/list_folder/continue"}, requests)
+
+	it = c.Files.ListFolderIterator(&ListFolderInput{Path: "/"}).From("expired")
+	assert.False(t, it.Next(ctx))
+	assert.True(t, hasTag(it.Err().(*Error), "reset"))
+}
+
+func TestIterator_cancel(t *testing.T) {
+	var requests []string
+	c := testClient(t, listFolderServer(t, &requests))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	it := c.Files.ListFolderIterator(&ListFolderInput{Path: "/"})
+	require.True(t, it.Next(ctx))
+	cancel()
+	assert.False(t, it.Next(ctx))
+	assert.Equal(t, context.Canceled, it.Err())
+	assert.Len(t, requests, 1)
+}
+
+func TestIterator_sharing(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/sharing/list_folders":
+			io.WriteString(w, `{"entries": [{"name": "one"}], "cursor": "c1"}`)
+		case "/2/sharing/list_folders/continue":
+			io.WriteString(w, `{"entries": [{"name": "two"}]}`)
+		case "/2/sharing/list_folder_members":
+			io.WriteString(w, `{"users": [{"user": {"account_id": "dbid:1"}}], "groups": [{"group": {"group_name": "g"}}], "invitees": [], "cursor": "c1"}`)
+		case "/2/sharing/list_folder_members/continue":
+			io.WriteString(w, `{"users": [], "groups": [], "invitees": [{"invitee": {".tag": "email", "email": "a@example.com"}}]}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	folders := c.Sharing.ListSharedFoldersIterator(&ListSharedFolderInput{Limit: 1})
+	var names []string
+	for folders.Next(ctx) {
+		names = append(names, folders.Value().Name)
+	}
+	require.NoError(t, folders.Err())
+	assert.Equal(t, []string{"one", "two"}, names)
+
+	members := c.Sharing.ListSharedFolderMembersIterator(&ListSharedFolderMembersInput{SharedFolderID: "1"})
+	var kinds []string
+	for members.Next(ctx) {
+		switch m := members.Value(); {
+		case m.User != nil:
+			kinds = append(kinds, "user")
+		case m.Group != nil:
+			kinds = append(kinds, "group")
+		case m.Invitee != nil:
+			kinds = append(kinds, "invitee")
+		}
+	}
+	require.NoError(t, members.Err())
+	assert.Equal(t, []string{"user", "group", "invitee"}, kinds)
+}
+
+func TestIterator_paper(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/paper/docs/list":
+			io.WriteString(w, `{"doc_ids": ["a", "b"], "cursor": {"value": "c1"}, "has_more": true}`)
+		case "/2/paper/docs/list/continue":
+			io.WriteString(w, `{"doc_ids": ["c"], "cursor": {"value": "c2"}, "has_more": false}`)
+		}
+	})
+
+	it := c.Paper.ListDocsIterator(&PaperDocsListInput{Limit: 2})
+	var ids []string
+	for it.Next(ctx) {
+		ids = append(ids, it.Value())
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	assert.Equal(t, "c2", it.Cursor())
+}
@@ -0,0 +1,131 @@
+package dropbox
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Call is a request to a Dropbox API endpoint, as seen by a Middleware. Each
+// attempt of a retried request is a separate call.
+type Call struct {
+	Route   string        // endpoint, such as "/files/get_metadata"
+	Host    string        // "api", "content" or "notify"
+	Arg     []byte        // JSON argument, sent in the body or the Dropbox-API-Arg header
+	Attempt int           // 1 for the first attempt, then incremented for each retry
+	Request *http.Request // may be replaced, for example to add a header
+}
+
+// Handler sends a call. A response with an error status is returned as an
+// *Error rather than a response, see StatusCode.
+type Handler func(call *Call) (*http.Response, error)
+
+// Middleware wraps the handler sending every call of a client, to observe or
+// alter them. Middleware is set on Config.Middleware, and applies to both
+// rpc and content endpoints.
+//
+//	func timing(next dropbox.Handler) dropbox.Handler {
+//		return func(call *dropbox.Call) (*http.Response, error) {
+//			start := time.Now()
+//			res, err := next(call)
+//			log.Printf("%s took %s", call.Route, time.Since(start))
+//			return res, err
+//		}
+//	}
+type Middleware func(next Handler) Handler
+
+// StatusCode returns the HTTP status of the outcome of a call, which is zero
+// when no response was received.
+func StatusCode(res *http.Response, err error) int {
+	if e, ok := err.(*Error); ok {
+		return e.StatusCode
+	}
+	if res != nil {
+		return res.StatusCode
+	}
+	return 0
+}
+
+// handler returns the config's middleware chain ending in send. The first
+// middleware is the outermost.
+func (c *Client) handler() Handler {
+	h := Handler(func(call *Call) (*http.Response, error) {
+		res, err := c.send(call.Request)
+		if e, ok := err.(*Error); ok {
+			e.Route = call.Route
+		}
+		return res, err
+	})
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		h = c.Middleware[i](h)
+	}
+	return h
+}
+
+// LoggingMiddleware logs every call to logger at the info level, or the
+// error level when it fails, with its route, argument, attempt, status,
+// duration and request ID. The access token in the Authorization header is
+// never logged.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			res, err := next(call)
+
+			attrs := []slog.Attr{
+				slog.String("route", call.Route),
+				slog.String("host", call.Host),
+				slog.String("arg", string(call.Arg)),
+				slog.Int("attempt", call.Attempt),
+				slog.Int("status", StatusCode(res, err)),
+				slog.Duration("duration", time.Since(start)),
+				slog.Any("header", redactHeader(call.Request.Header)),
+			}
+
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.String("error", err.Error()))
+				if e, ok := err.(*Error); ok && e.RequestID != "" {
+					attrs = append(attrs, slog.String("request_id", e.RequestID))
+				}
+			} else if id := res.Header.Get("X-Dropbox-Request-Id"); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+
+			logger.LogAttrs(call.Request.Context(), level, "dropbox request", attrs...)
+			return res, err
+		}
+	}
+}
+
+// redactHeader returns a copy of the request header with the access token
+// removed.
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	if h.Get("Authorization") != "" {
+		h.Set("Authorization", "Bearer REDACTED")
+	}
+	return h
+}
+
+// Metrics receives a measurement of every call, see MetricsMiddleware.
+type Metrics interface {
+	// ObserveCall records a call of route which completed with the HTTP
+	// status, zero when no response was received, after duration.
+	ObserveCall(ctx context.Context, route string, status int, duration time.Duration)
+}
+
+// MetricsMiddleware reports every call to m, to count calls and measure
+// their latency by route and status.
+func MetricsMiddleware(m Metrics) Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			res, err := next(call)
+			m.ObserveCall(call.Request.Context(), call.Route, StatusCode(res, err), time.Since(start))
+			return res, err
+		}
+	}
+}
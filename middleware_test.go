@@ -0,0 +1,122 @@
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	attempts := 0
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/2/files/download" {
+			w.Header().Set("Dropbox-API-Result", `{"name": "hello.txt"}`)
+			io.WriteString(w, "hello")
+			return
+		}
+
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+	})
+	c.Retry = testRetryPolicy
+
+	var log []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(call *Call) (*http.Response, error) {
+				log = append(log, name+" "+call.Route)
+				res, err := next(call)
+				log = append(log, name+" "+call.Host+" "+string(call.Arg)+" "+http.StatusText(StatusCode(res, err)))
+				return res, err
+			}
+		}
+	}
+	c.Middleware = []Middleware{trace("a"), trace("b")}
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	out.Body.Close()
+
+	assert.Equal(t, []string{
+		"a /files/get_metadata",
+		"b /files/get_metadata",
+		`b api {"path":"/hello.txt","include_media_info":false} Service Unavailable`,
+		`a api {"path":"/hello.txt","include_media_info":false} Service Unavailable`,
+		"a /files/get_metadata",
+		"b /files/get_metadata",
+		`b api {"path":"/hello.txt","include_media_info":false} OK`,
+		`a api {"path":"/hello.txt","include_media_info":false} OK`,
+		"a /files/download",
+		"b /files/download",
+		`b content {"path":"/hello.txt"} OK`,
+		`a content {"path":"/hello.txt"} OK`,
+	}, log)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Dropbox-Request-Id", "a1b2c3")
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, `{"error_summary": "path/not_found/..", "error": {".tag": "path", "path": {".tag": "not_found"}}}`)
+	})
+
+	c.AccessToken = "sl.secret"
+	var buf bytes.Buffer
+	c.Middleware = []Middleware{LoggingMiddleware(slog.New(slog.NewJSONHandler(&buf, nil)))}
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.Error(t, err)
+
+	assert.NotContains(t, buf.String(), "sl.secret")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "/files/get_metadata", entry["route"])
+	assert.Equal(t, float64(409), entry["status"])
+	assert.Equal(t, float64(1), entry["attempt"])
+	assert.Equal(t, "a1b2c3", entry["request_id"])
+	assert.Equal(t, "path/not_found/..", entry["error"])
+	assert.True(t, strings.HasPrefix(entry["arg"].(string), `{"path":"/hello.txt"`))
+	assert.Equal(t, []interface{}{"Bearer REDACTED"}, entry["header"].(map[string]interface{})["Authorization"])
+}
+
+type testMetrics map[string]int
+
+func (m testMetrics) ObserveCall(ctx context.Context, route string, status int, duration time.Duration) {
+	m[route+" "+http.StatusText(status)]++
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+	})
+
+	m := testMetrics{}
+	c.Middleware = []Middleware{MetricsMiddleware(m)}
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, testMetrics{"/files/get_metadata OK": 2}, m)
+}
@@ -0,0 +1,104 @@
+package dropbox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CallOption overrides the client config for calls made with a context
+// returned by WithCallOptions.
+type CallOption func(*callOptions)
+
+// callOptions holds the overrides carried by a context.
+type callOptions struct {
+	namespace   *APIPathRoot
+	selectUser  string
+	selectAdmin string
+	header      http.Header
+	timeout     time.Duration
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context which applies opts to every call made
+// with it, in addition to any options already carried by ctx. This allows a
+// single client to act on many namespaces or team members.
+//
+//	ctx := dropbox.WithCallOptions(ctx,
+//		dropbox.WithPathRoot(dropbox.NamespaceIDNamespace(id)),
+//		dropbox.WithTimeout(30*time.Second))
+//	out, err := client.Files.ListFolder(ctx, in)
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := callOptionsFrom(ctx)
+	o.header = o.header.Clone()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+// WithPathRoot sends calls relative to the given namespace, overriding
+// Config.Namespace.
+func WithPathRoot(root *APIPathRoot) CallOption {
+	return func(o *callOptions) {
+		o.namespace = root
+	}
+}
+
+// WithSelectUser acts as the given team member, overriding the team member
+// selection of the config.
+func WithSelectUser(memberID string) CallOption {
+	return func(o *callOptions) {
+		o.selectUser = memberID
+		o.selectAdmin = ""
+	}
+}
+
+// WithSelectAdmin acts as the given team admin, overriding the team member
+// selection of the config.
+func WithSelectAdmin(memberID string) CallOption {
+	return func(o *callOptions) {
+		o.selectAdmin = memberID
+		o.selectUser = ""
+	}
+}
+
+// WithHeader sets an extra HTTP header on calls. The Authorization header is
+// always set by the client.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Set(key, value)
+	}
+}
+
+// WithTimeout limits each call to d, including its retries and the reading
+// of its response body.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// callOptionsFrom returns the call options of ctx.
+func callOptionsFrom(ctx context.Context) callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return o
+}
+
+// cancelBody releases the timeout of a call when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implementation.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
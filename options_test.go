@@ -0,0 +1,103 @@
+package dropbox
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCallOptions(t *testing.T) {
+	var headers []http.Header
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		if r.URL.Path == "/2/files/download" {
+			w.Header().Set("Dropbox-API-Result", `{"name": "hello.txt"}`)
+			io.WriteString(w, "hello")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+	})
+	c.Namespace = HomeNamespace
+	c.SelectAdmin = "dbmid:admin"
+
+	ctx := WithCallOptions(ctx, WithPathRoot(NamespaceIDNamespace("123")), WithHeader("X-Trace", "a"))
+	ctx = WithCallOptions(ctx, WithSelectUser("dbmid:user"), WithHeader("X-Trace", "b"))
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	out.Body.Close()
+
+	_, err = c.Files.GetMetadata(context.Background(), &GetMetadataInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+
+	require.Len(t, headers, 3)
+	for _, h := range headers[:2] {
+		assert.Equal(t, `{".tag":"namespace_id","namespace_id":"123"}`, h.Get("Dropbox-API-Path-Root"))
+		assert.Equal(t, "dbmid:user", h.Get("Dropbox-API-Select-User"))
+		assert.Empty(t, h.Get("Dropbox-API-Select-Admin"))
+		assert.Equal(t, "b", h.Get("X-Trace"))
+		assert.Equal(t, "Bearer token", h.Get("Authorization"))
+	}
+
+	h := headers[2]
+	assert.Equal(t, `{".tag":"home"}`, h.Get("Dropbox-API-Path-Root"))
+	assert.Equal(t, "dbmid:admin", h.Get("Dropbox-API-Select-Admin"))
+	assert.Empty(t, h.Get("Dropbox-API-Select-User"))
+	assert.Empty(t, h.Get("X-Trace"))
+}
+
+func TestWithCallOptions_longpoll(t *testing.T) {
+	var header http.Header
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"changes": false}`)
+	})
+	c.Namespace = HomeNamespace
+	c.SelectUser = "dbmid:user"
+
+	ctx := WithCallOptions(ctx, WithSelectAdmin("dbmid:admin"), WithHeader("X-Trace", "a"))
+	_, err := c.Files.ListFolderLongpoll(ctx, &ListFolderLongpollInput{Cursor: "c1"})
+	require.NoError(t, err)
+
+	assert.Empty(t, header.Get("Authorization"))
+	assert.Empty(t, header.Get("Dropbox-API-Path-Root"))
+	assert.Empty(t, header.Get("Dropbox-API-Select-User"))
+	assert.Empty(t, header.Get("Dropbox-API-Select-Admin"))
+	assert.Equal(t, "a", header.Get("X-Trace"))
+}
+
+func TestWithTimeout(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/2/files/get_metadata" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		w.Header().Set("Dropbox-API-Result", `{"name": "hello.txt"}`)
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "hello")
+	})
+
+	ctx := WithCallOptions(ctx, WithTimeout(50*time.Millisecond))
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	out, err := c.Files.Download(ctx, &DownloadInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(out.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.NoError(t, out.Body.Close())
+}
@@ -119,14 +119,33 @@ type PaperDownloadInput struct {
 	ExportFormat string `json:"export_format"`
 }
 
+// PaperDownloadOutput is the response for /paper/docs/download, with the
+// document's details from the Dropbox-API-Result header.
+type PaperDownloadOutput struct {
+	Body        io.ReadCloser `json:"-"`
+	Length      int64         `json:"-"`
+	ContentType string        `json:"-"`
+	Owner       string        `json:"owner"`
+	Title       string        `json:"title"`
+	Revision    int64         `json:"revision"`
+	MimeType    string        `json:"mime_type"`
+}
+
 // Download a Dropbox Paper.
-func (c *Paper) Download(ctx context.Context, in *PaperDownloadInput) (out *DownloadOutput, err error) {
-	body, l, err := c.download(ctx, "api", "/paper/docs/download", in, nil)
+func (c *Paper) Download(ctx context.Context, in *PaperDownloadInput) (out *PaperDownloadOutput, err error) {
+	res, err := c.download(ctx, "api", "/paper/docs/download", in, nil)
 	if err != nil {
 		return
 	}
 
-	out = &DownloadOutput{body, l}
+	out = &PaperDownloadOutput{
+		Body:        res.Body,
+		Length:      res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if err = apiResult(res, out); err != nil {
+		out = nil
+	}
 	return
 }
 
@@ -191,13 +210,13 @@ type PaperCreateOutput struct {
 
 // Create creates a Dropbox Paper file on a user's Dropbox Paper.
 func (c *Paper) Create(ctx context.Context, in *PaperCreateInput) (out *PaperCreateOutput, err error) {
-	body, _, err := c.download(ctx, "api", "/paper/docs/create", in, in.Reader)
+	res, err := c.download(ctx, "api", "/paper/docs/create", in, in.Reader)
 	if err != nil {
 		return
 	}
-	defer body.Close()
+	defer res.Body.Close()
 
-	err = json.NewDecoder(body).Decode(&out)
+	err = json.NewDecoder(res.Body).Decode(&out)
 	return
 }
 
@@ -3,8 +3,10 @@ package dropbox
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"os"
 	"strings"
 	"testing"
@@ -17,7 +19,7 @@ import (
 // Paper directory tree.
 func TestPaper_List(t *testing.T) {
 	ctx := context.Background()
-	c := client()
+	c := client(t)
 
 	out, err := c.Paper.ListDocs(ctx, &PaperDocsListInput{
 		SortBy:    "modified",
@@ -46,7 +48,7 @@ func setupPaperDocument(ctx context.Context, t *testing.T, c *Client) (string, f
 
 func TestPaper_CreateDeletePermanently(t *testing.T) {
 	ctx := context.Background()
-	c := client()
+	c := client(t)
 	title := dummyPaperTitle()
 
 	// Create a Dropbox Paper
@@ -80,7 +82,7 @@ func TestPaper_CreateDeletePermanently(t *testing.T) {
 
 func TestPaper_Download(t *testing.T) {
 	ctx := context.Background()
-	c := client()
+	c := client(t)
 	fileID, deleteFile := setupPaperDocument(ctx, t, c)
 	defer deleteFile()
 
@@ -94,11 +96,15 @@ func TestPaper_Download(t *testing.T) {
 	content, err := ioutil.ReadAll(out.Body)
 	require.NoError(t, err)
 	assert.Equal(t, out.Length, int64(len(content)))
+	assert.NotZero(t, out.Owner)
+	assert.NotZero(t, out.Title)
+	assert.NotZero(t, out.Revision)
+	assert.Contains(t, out.MimeType, "markdown")
 }
 
 func TestPaper_GetFolderInfoTopLevel(t *testing.T) {
 	ctx := context.Background()
-	c := client()
+	c := client(t)
 
 	fileID, deleteFile := setupPaperDocument(ctx, t, c)
 	defer deleteFile()
@@ -126,7 +132,7 @@ func TestPaper_GetFolderInfoTopLevel(t *testing.T) {
 
 func TestPaper_GetFolderInfoInsideFolders(t *testing.T) {
 	ctx := context.Background()
-	c := client()
+	c := client(t)
 
 	var fileID string
 	if fileID = os.Getenv("DROPBOX_PAPER_NESTED_FILE_ID"); fileID == "" {
@@ -145,7 +151,7 @@ func TestPaper_GetFolderInfoInsideFolders(t *testing.T) {
 
 func TestPaper_AlphaGetMetadata(t *testing.T) {
 	ctx := context.Background()
-	c := client()
+	c := client(t)
 
 	fileID, deleteFile := setupPaperDocument(ctx, t, c)
 	defer deleteFile()
@@ -161,3 +167,25 @@ func TestPaper_AlphaGetMetadata(t *testing.T) {
 	assert.NotZero(t, out.Revision)
 	assert.NotZero(t, out.LastEditor)
 }
+
+func TestPaper_Download_metadata(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/paper/docs/download", r.URL.Path)
+		w.Header().Set("Content-Type", "text/markdown")
+		w.Header().Set("Dropbox-API-Result", `{"owner": "james@example.com", "title": "Roadmap", "revision": 456736745, "mime_type": "text/x-markdown"}`)
+		io.WriteString(w, "# Roadmap")
+	})
+
+	out, err := c.Paper.Download(context.Background(), &PaperDownloadInput{
+		DocID:        "uaSvRuxvnkFa12PTkBv5q",
+		ExportFormat: ExportFormatMarkdown,
+	})
+	require.NoError(t, err)
+	defer out.Body.Close()
+
+	assert.Equal(t, "james@example.com", out.Owner)
+	assert.Equal(t, "Roadmap", out.Title)
+	assert.Equal(t, int64(456736745), out.Revision)
+	assert.Equal(t, "text/x-markdown", out.MimeType)
+	assert.Equal(t, "text/markdown", out.ContentType)
+}
@@ -0,0 +1,163 @@
+package dropbox
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy determines which failed requests are retried, and how long to
+// wait between attempts. A Retry-After header or retry_after error field
+// returned by Dropbox takes precedence over the computed backoff.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; 1 or less disables retries
+	MinBackoff  time.Duration // delay before the first retry, doubled for each further attempt
+	MaxBackoff  time.Duration // upper bound on the computed delay
+	Jitter      float64       // fraction of each delay which is randomized, from 0 to 1
+	Statuses    []int         // HTTP status codes which are retried
+	Tags        []string      // error tags which are retried, matched at any depth
+}
+
+// DefaultRetryPolicy retries rate limited and transient server errors.
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts: 5,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+	Jitter:      0.2,
+	Statuses: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+	Tags: []string{
+		TooManyRequests,
+		TooManyWriteOperations,
+	},
+}
+
+// NotReplayableError is returned instead of a retryable error when the request
+// body is a reader which cannot be rewound, such as a pipe. Pass an io.Seeker,
+// or a *bytes.Buffer, *bytes.Reader or *strings.Reader to allow retries.
+type NotReplayableError struct {
+	Err *Error
+}
+
+// Error string.
+func (e *NotReplayableError) Error() string {
+	return fmt.Sprintf("dropbox: not retrying, request body cannot be replayed: %s", e.Err)
+}
+
+// Unwrap returns the Dropbox error which would have been retried.
+func (e *NotReplayableError) Unwrap() error {
+	return e.Err
+}
+
+// attempts returns the maximum number of attempts for a request.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable reports whether the error should be retried.
+func (p *RetryPolicy) retryable(e *Error) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, status := range p.Statuses {
+		if e.StatusCode == status {
+			return true
+		}
+	}
+
+	for _, tag := range p.Tags {
+		if hasTag(e, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before the given retry attempt, where attempt 1
+// is the first retry.
+func (p *RetryPolicy) backoff(attempt int, e *Error) time.Duration {
+	if e.RetryAfter > 0 || e.retryAfterSet {
+		return e.RetryAfter
+	}
+
+	// Without a MaxBackoff, doubling stops short of overflowing even once
+	// the jitter is added.
+	d := p.MinBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff) && d <= math.MaxInt64/4; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		j := time.Duration(p.Jitter * float64(d))
+		d = d - j + time.Duration(rand.Int63n(int64(2*j)+1))
+	}
+
+	return d
+}
+
+// retryAfter returns the delay requested by Dropbox, either through the
+// Retry-After header or the retry_after field of a rate limit error, and
+// whether one was requested at all. A zero delay, or a date in the past,
+// asks for an immediate retry.
+func retryAfter(e *Error) (time.Duration, bool) {
+	if v := e.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return nonNegative(time.Duration(secs) * time.Second), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(time.Until(t)), true
+		}
+	}
+
+	if payload, ok := e.Err.(map[string]interface{}); ok {
+		if secs, ok := payload["retry_after"].(float64); ok {
+			return nonNegative(time.Duration(secs * float64(time.Second))), true
+		}
+	}
+
+	return 0, false
+}
+
+// nonNegative clamps a delay which has already passed to zero.
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// rateLimitReason returns the reason a request was rate limited, which is
+// either given by a 429 response or nested in an endpoint error.
+func rateLimitReason(e *Error) string {
+	if payload, ok := e.Err.(map[string]interface{}); ok {
+		if reason, ok := payload["reason"].(map[string]interface{}); ok {
+			if tag, ok := reason[".tag"].(string); ok {
+				return tag
+			}
+		}
+	}
+
+	switch {
+	case hasTag(e, TooManyWriteOperations):
+		return TooManyWriteOperations
+	case hasTag(e, TooManyRequests), e.StatusCode == http.StatusTooManyRequests:
+		return TooManyRequests
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package dropbox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = &RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  time.Millisecond,
+	MaxBackoff:  time.Millisecond,
+	Statuses:    []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	Tags:        []string{TooManyWriteOperations},
+}
+
+func TestRetry_status(t *testing.T) {
+	attempts := 0
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+	})
+	c.Retry = testRetryPolicy
+
+	out, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello.txt", out.Entry().Name)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetry_exhausted(t *testing.T) {
+	attempts := 0
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error_summary": "too_many_requests/..", "error": {"reason": {".tag": "too_many_requests"}, "retry_after": 0}}`)
+	})
+	c.Retry = testRetryPolicy
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	assert.Error(t, err)
+	assert.Equal(t, 429, err.(*Error).StatusCode)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetry_tagReplaysUpload(t *testing.T) {
+	var bodies []string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.Header().Set("Content-Type", "application/json")
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{"error_summary": "path/too_many_write_operations/..", "error": {".tag": "path"}}`)
+			return
+		}
+		io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+	})
+	c.Retry = testRetryPolicy
+
+	_, err := c.Files.Upload(ctx, &UploadInput{
+		Path:   "/hello.txt",
+		Reader: bytes.NewReader([]byte("hello")),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello", "hello"}, bodies)
+}
+
+func TestRetry_notReplayable(t *testing.T) {
+	attempts := 0
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.Retry = testRetryPolicy
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("hello"))
+		pw.Close()
+	}()
+
+	_, err := c.Files.Upload(ctx, &UploadInput{
+		Path:   "/hello.txt",
+		Reader: pr,
+	})
+
+	var nr *NotReplayableError
+	assert.True(t, errors.As(err, &nr), "error should refuse the retry")
+	assert.Equal(t, 503, nr.Err.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	e := &Error{Header: http.Header{}}
+
+	assert.Equal(t, time.Second, p.backoff(1, e))
+	assert.Equal(t, 2*time.Second, p.backoff(2, e))
+	assert.Equal(t, 4*time.Second, p.backoff(3, e))
+	assert.Equal(t, 5*time.Second, p.backoff(4, e))
+
+	e.RetryAfter = 7 * time.Second
+	assert.Equal(t, 7*time.Second, p.backoff(1, e))
+}
+
+func TestRetryPolicy_backoff_unbounded(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: 500 * time.Millisecond, Jitter: 0.2}
+	e := &Error{Header: http.Header{}}
+
+	for _, attempt := range []int{30, 34, 40, 100, 1000} {
+		d := p.backoff(attempt, e)
+		assert.True(t, d > 0, "attempt %d: %s", attempt, d)
+	}
+}
+
+func TestRetry_retryAfterZero(t *testing.T) {
+	for _, v := range []string{"0", "Mon, 02 Jan 2006 15:04:05 GMT"} {
+		e := &Error{Header: http.Header{"Retry-After": {v}}}
+		e.RetryAfter, e.retryAfterSet = retryAfter(e)
+
+		p := &RetryPolicy{MinBackoff: time.Hour}
+		assert.Equal(t, time.Duration(0), p.backoff(1, e), v)
+	}
+
+	e := &Error{Header: http.Header{}}
+	e.RetryAfter, e.retryAfterSet = retryAfter(e)
+	assert.False(t, e.retryAfterSet)
+}
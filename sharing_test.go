@@ -7,7 +7,7 @@ import (
 )
 
 func TestSharing_CreateSharedLink(t *testing.T) {
-	c := client()
+	c := client(t)
 	out, err := c.Sharing.CreateSharedLink(ctx, &CreateSharedLinkInput{
 		Path:     "/hello.txt",
 		ShortURL: true,
@@ -18,7 +18,7 @@ func TestSharing_CreateSharedLink(t *testing.T) {
 }
 
 func TestSharing_ListSharedFolder(t *testing.T) {
-	c := client()
+	c := client(t)
 	out, err := c.Sharing.ListSharedFolders(ctx, &ListSharedFolderInput{
 		Limit: 1,
 	})
@@ -60,7 +60,7 @@ func TestSharing_ListSharedFolder(t *testing.T) {
 }
 
 func TestSharing_ListSharedFile(t *testing.T) {
-	c := client()
+	c := client(t)
 	out, err := c.Sharing.ListSharedFileMembers(ctx, &ListSharedFileMembersInput{
 		File:             "/hello.txt",
 		IncludeInherited: true,
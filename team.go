@@ -0,0 +1,386 @@
+package dropbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Team client for Dropbox Business teams, which requires a team access token.
+type Team struct {
+	*Client
+}
+
+// NewTeam client.
+func NewTeam(config *Config) *Team {
+	return &Team{
+		Client: &Client{
+			Config: config,
+		},
+	}
+}
+
+// AsMember returns a client which acts as the given team member, so the
+// Files, Sharing, Paper and Users endpoints operate on their Dropbox. The
+// Team endpoints of the returned client still act on the team.
+func (c *Team) AsMember(teamMemberID string) *Client {
+	config := *c.Config
+	config.SelectUser = teamMemberID
+	config.SelectAdmin = ""
+	return New(&config)
+}
+
+// AsAdmin returns a client which acts as the given team admin. Endpoints
+// which accept it, such as those for team folders, operate with the admin's
+// access, while member endpoints only see the admin's own Dropbox.
+func (c *Team) AsAdmin(teamMemberID string) *Client {
+	config := *c.Config
+	config.SelectUser = ""
+	config.SelectAdmin = teamMemberID
+	return New(&config)
+}
+
+// TeamMemberPolicies are the policies which apply to the members of a team.
+type TeamMemberPolicies struct {
+	Sharing  TeamSharingPolicies `json:"sharing"`
+	EmmState struct {
+		Tag string `json:".tag"`
+	} `json:"emm_state"`
+	OfficeAddin struct {
+		Tag string `json:".tag"`
+	} `json:"office_addin"`
+	SuggestMembersPolicy struct {
+		Tag string `json:".tag"`
+	} `json:"suggest_members_policy"`
+}
+
+// GetTeamInfoOutput request output.
+type GetTeamInfoOutput struct {
+	Name                string             `json:"name"`
+	TeamID              string             `json:"team_id"`
+	NumLicensedUsers    uint32             `json:"num_licensed_users"`
+	NumProvisionedUsers uint32             `json:"num_provisioned_users"`
+	NumUsedLicenses     uint32             `json:"num_used_licenses"`
+	Policies            TeamMemberPolicies `json:"policies"`
+}
+
+// GetInfo returns information about the team.
+func (c *Team) GetInfo(ctx context.Context) (out *GetTeamInfoOutput, err error) {
+	body, err := c.call(ctx, "/team/get_info", nil)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// TeamMemberProfile is the profile of a team member.
+type TeamMemberProfile struct {
+	TeamMemberID    string `json:"team_member_id"`
+	AccountID       string `json:"account_id"`
+	ExternalID      string `json:"external_id"`
+	PersistentID    string `json:"persistent_id"`
+	Email           string `json:"email"`
+	EmailVerified   bool   `json:"email_verified"`
+	SecondaryEmails []struct {
+		Email      string `json:"email"`
+		IsVerified bool   `json:"is_verified"`
+	} `json:"secondary_emails"`
+	Status struct {
+		Tag string `json:".tag"`
+	} `json:"status"`
+	Name struct {
+		GivenName    string `json:"given_name"`
+		Surname      string `json:"surname"`
+		FamiliarName string `json:"familiar_name"`
+		DisplayName  string `json:"display_name"`
+	} `json:"name"`
+	MembershipType struct {
+		Tag string `json:".tag"`
+	} `json:"membership_type"`
+	Groups                []string  `json:"groups"`
+	MemberFolderID        string    `json:"member_folder_id"`
+	RootFolderID          string    `json:"root_folder_id"`
+	JoinedOn              time.Time `json:"joined_on"`
+	SuspendedOn           time.Time `json:"suspended_on"`
+	InvitedOn             time.Time `json:"invited_on"`
+	IsDirectoryRestricted bool      `json:"is_directory_restricted"`
+	ProfilePhotoURL       string    `json:"profile_photo_url"`
+}
+
+// TeamMemberRole is an admin role held by a team member.
+type TeamMemberRole struct {
+	RoleID      string `json:"role_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TeamMember is a team member's profile and roles.
+type TeamMember struct {
+	Profile TeamMemberProfile `json:"profile"`
+	Roles   []TeamMemberRole  `json:"roles"`
+}
+
+// ListTeamMembersInput request input.
+type ListTeamMembersInput struct {
+	Limit          uint32 `json:"limit,omitempty"`
+	IncludeRemoved bool   `json:"include_removed"`
+}
+
+// ListTeamMembersOutput request output.
+type ListTeamMembersOutput struct {
+	Members []*TeamMember `json:"members"`
+	Cursor  string        `json:"cursor"`
+	HasMore bool          `json:"has_more"`
+}
+
+// ListMembers returns the first page of team members.
+func (c *Team) ListMembers(ctx context.Context, in *ListTeamMembersInput) (out *ListTeamMembersOutput, err error) {
+	body, err := c.call(ctx, "/team/members/list_v2", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// ListTeamMembersContinueInput request input.
+type ListTeamMembersContinueInput struct {
+	Cursor string `json:"cursor"`
+}
+
+// ListMembersContinue returns the next page of team members.
+func (c *Team) ListMembersContinue(ctx context.Context, in *ListTeamMembersContinueInput) (out *ListTeamMembersOutput, err error) {
+	body, err := c.call(ctx, "/team/members/list/continue_v2", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// UserSelector identifies a team member by one of its IDs or email.
+type UserSelector struct {
+	Tag          string `json:".tag"`
+	TeamMemberID string `json:"team_member_id,omitempty"`
+	ExternalID   string `json:"external_id,omitempty"`
+	Email        string `json:"email,omitempty"`
+}
+
+// SelectTeamMemberID returns a UserSelector for the given team member ID.
+func SelectTeamMemberID(id string) UserSelector {
+	return UserSelector{Tag: "team_member_id", TeamMemberID: id}
+}
+
+// SelectExternalID returns a UserSelector for the given external ID.
+func SelectExternalID(id string) UserSelector {
+	return UserSelector{Tag: "external_id", ExternalID: id}
+}
+
+// SelectEmail returns a UserSelector for the given email.
+func SelectEmail(email string) UserSelector {
+	return UserSelector{Tag: "email", Email: email}
+}
+
+// GetTeamMembersInfoInput request input. At most 100 members may be listed.
+type GetTeamMembersInfoInput struct {
+	Members []UserSelector `json:"members"`
+}
+
+// TeamMemberInfoResult is the result for one member of GetMembersInfo. The tag
+// is "member_info", or "id_not_found" with the unknown ID in IDNotFound.
+type TeamMemberInfoResult struct {
+	Tag        string `json:".tag"`
+	IDNotFound string `json:"id_not_found,omitempty"`
+	TeamMember
+}
+
+// GetTeamMembersInfoOutput request output.
+type GetTeamMembersInfoOutput struct {
+	MembersInfo []*TeamMemberInfoResult `json:"members_info"`
+}
+
+// GetMembersInfo returns information about the given team members, in the
+// same order.
+func (c *Team) GetMembersInfo(ctx context.Context, in *GetTeamMembersInfoInput) (out *GetTeamMembersInfoOutput, err error) {
+	body, err := c.call(ctx, "/team/members/get_info_v2", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// GroupSummary is the summary of a team group.
+type GroupSummary struct {
+	GroupName           string `json:"group_name"`
+	GroupID             string `json:"group_id"`
+	GroupExternalID     string `json:"group_external_id"`
+	MemberCount         uint32 `json:"member_count"`
+	GroupManagementType struct {
+		Tag string `json:".tag"`
+	} `json:"group_management_type"`
+}
+
+// ListGroupsInput request input.
+type ListGroupsInput struct {
+	Limit uint32 `json:"limit,omitempty"`
+}
+
+// ListGroupsOutput request output.
+type ListGroupsOutput struct {
+	Groups  []*GroupSummary `json:"groups"`
+	Cursor  string          `json:"cursor"`
+	HasMore bool            `json:"has_more"`
+}
+
+// ListGroups returns the first page of groups on the team.
+func (c *Team) ListGroups(ctx context.Context, in *ListGroupsInput) (out *ListGroupsOutput, err error) {
+	body, err := c.call(ctx, "/team/groups/list", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// ListGroupsContinueInput request input.
+type ListGroupsContinueInput struct {
+	Cursor string `json:"cursor"`
+}
+
+// ListGroupsContinue returns the next page of groups on the team.
+func (c *Team) ListGroupsContinue(ctx context.Context, in *ListGroupsContinueInput) (out *ListGroupsOutput, err error) {
+	body, err := c.call(ctx, "/team/groups/list/continue", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// GroupSelector identifies a group by its ID or external ID.
+type GroupSelector struct {
+	Tag             string `json:".tag"`
+	GroupID         string `json:"group_id,omitempty"`
+	GroupExternalID string `json:"group_external_id,omitempty"`
+}
+
+// SelectGroupID returns a GroupSelector for the given group ID.
+func SelectGroupID(id string) GroupSelector {
+	return GroupSelector{Tag: "group_id", GroupID: id}
+}
+
+// GroupMember is a member of a group.
+type GroupMember struct {
+	Profile    TeamMemberProfile `json:"profile"`
+	AccessType struct {
+		Tag string `json:".tag"`
+	} `json:"access_type"`
+}
+
+// ListGroupMembersInput request input.
+type ListGroupMembersInput struct {
+	Group GroupSelector `json:"group"`
+	Limit uint32        `json:"limit,omitempty"`
+}
+
+// ListGroupMembersOutput request output.
+type ListGroupMembersOutput struct {
+	Members []*GroupMember `json:"members"`
+	Cursor  string         `json:"cursor"`
+	HasMore bool           `json:"has_more"`
+}
+
+// ListGroupMembers returns the first page of members of a group.
+func (c *Team) ListGroupMembers(ctx context.Context, in *ListGroupMembersInput) (out *ListGroupMembersOutput, err error) {
+	body, err := c.call(ctx, "/team/groups/members/list", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// ListGroupMembersContinueInput request input.
+type ListGroupMembersContinueInput struct {
+	Cursor string `json:"cursor"`
+}
+
+// ListGroupMembersContinue returns the next page of members of a group.
+func (c *Team) ListGroupMembersContinue(ctx context.Context, in *ListGroupMembersContinueInput) (out *ListGroupMembersOutput, err error) {
+	body, err := c.call(ctx, "/team/groups/members/list/continue", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// NamespaceMetadata describes a team namespace, such as a team folder or a
+// member's home folder.
+type NamespaceMetadata struct {
+	Name          string `json:"name"`
+	NamespaceID   string `json:"namespace_id"`
+	NamespaceType struct {
+		Tag string `json:".tag"`
+	} `json:"namespace_type"`
+	TeamMemberID string `json:"team_member_id"`
+}
+
+// ListNamespacesInput request input.
+type ListNamespacesInput struct {
+	Limit uint32 `json:"limit,omitempty"`
+}
+
+// ListNamespacesOutput request output.
+type ListNamespacesOutput struct {
+	Namespaces []*NamespaceMetadata `json:"namespaces"`
+	Cursor     string               `json:"cursor"`
+	HasMore    bool                 `json:"has_more"`
+}
+
+// ListNamespaces returns the first page of namespaces on the team.
+func (c *Team) ListNamespaces(ctx context.Context, in *ListNamespacesInput) (out *ListNamespacesOutput, err error) {
+	body, err := c.call(ctx, "/team/namespaces/list", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// ListNamespacesContinueInput request input.
+type ListNamespacesContinueInput struct {
+	Cursor string `json:"cursor"`
+}
+
+// ListNamespacesContinue returns the next page of namespaces on the team.
+func (c *Team) ListNamespacesContinue(ctx context.Context, in *ListNamespacesContinueInput) (out *ListNamespacesOutput, err error) {
+	body, err := c.call(ctx, "/team/namespaces/list/continue", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
@@ -0,0 +1,274 @@
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Team event categories accepted by GetTeamEventsInput.
+const (
+	TeamEventCategoryApps         = "apps"
+	TeamEventCategoryDevices      = "devices"
+	TeamEventCategoryDomains      = "domains"
+	TeamEventCategoryFileOps      = "file_operations"
+	TeamEventCategoryFileRequests = "file_requests"
+	TeamEventCategoryGroups       = "groups"
+	TeamEventCategoryLogins       = "logins"
+	TeamEventCategoryMembers      = "members"
+	TeamEventCategoryPaper        = "paper"
+	TeamEventCategoryPasswords    = "passwords"
+	TeamEventCategoryReports      = "reports"
+	TeamEventCategorySharing      = "sharing"
+	TeamEventCategoryTeamFolders  = "team_folders"
+	TeamEventCategoryTeamPolicies = "team_policies"
+	TeamEventCategoryTeamProfile  = "team_profile"
+	TeamEventCategoryTFA          = "tfa"
+)
+
+// GetTeamEventsInput request input. Every filter is optional.
+type GetTeamEventsInput struct {
+	Limit     uint32    // at most 1000 events per page
+	AccountID string    // only events involving this account
+	StartTime time.Time // only events at or after this time
+	EndTime   time.Time // only events before this time
+	Category  string    // only events of this category, such as TeamEventCategorySharing
+	EventType string    // only events of this type, such as "shared_content_download"
+}
+
+// MarshalJSON encodes the filters, leaving out those which are unset.
+func (in *GetTeamEventsInput) MarshalJSON() ([]byte, error) {
+	type tag struct {
+		Tag string `json:".tag"`
+	}
+
+	type timeRange struct {
+		StartTime *time.Time `json:"start_time,omitempty"`
+		EndTime   *time.Time `json:"end_time,omitempty"`
+	}
+
+	v := struct {
+		Limit     uint32     `json:"limit,omitempty"`
+		AccountID string     `json:"account_id,omitempty"`
+		Time      *timeRange `json:"time,omitempty"`
+		Category  *tag       `json:"category,omitempty"`
+		EventType *tag       `json:"event_type,omitempty"`
+	}{
+		Limit:     in.Limit,
+		AccountID: in.AccountID,
+	}
+
+	if !in.StartTime.IsZero() || !in.EndTime.IsZero() {
+		v.Time = &timeRange{}
+		if !in.StartTime.IsZero() {
+			t := in.StartTime.UTC().Truncate(time.Second)
+			v.Time.StartTime = &t
+		}
+		if !in.EndTime.IsZero() {
+			t := in.EndTime.UTC().Truncate(time.Second)
+			v.Time.EndTime = &t
+		}
+	}
+
+	if in.Category != "" {
+		v.Category = &tag{in.Category}
+	}
+	if in.EventType != "" {
+		v.EventType = &tag{in.EventType}
+	}
+
+	return json.Marshal(v)
+}
+
+// UserLogInfo identifies a user in a team event.
+type UserLogInfo struct {
+	Tag              string `json:".tag"` // team_member, trusted_non_team_member or non_team_member
+	AccountID        string `json:"account_id"`
+	DisplayName      string `json:"display_name"`
+	Email            string `json:"email"`
+	TeamMemberID     string `json:"team_member_id"`
+	MemberExternalID string `json:"member_external_id"`
+}
+
+// AppLogInfo identifies an app in a team event.
+type AppLogInfo struct {
+	Tag         string `json:".tag"`
+	AppID       string `json:"app_id"`
+	DisplayName string `json:"display_name"`
+}
+
+// ActorLogInfo is who performed the action of a team event. The tag is
+// "user", "admin", "app", "reseller", "dropbox" or "anonymous".
+type ActorLogInfo struct {
+	Tag   string       `json:".tag"`
+	User  *UserLogInfo `json:"user,omitempty"`
+	Admin *UserLogInfo `json:"admin,omitempty"`
+	App   *AppLogInfo  `json:"app,omitempty"`
+}
+
+// ContextLogInfo is the user or team on whose behalf the action of a team
+// event was performed. The tag is "team_member", "non_team_member", "team",
+// "organization_team" or "anonymous".
+type ContextLogInfo struct {
+	Tag          string `json:".tag"`
+	AccountID    string `json:"account_id"`
+	DisplayName  string `json:"display_name"`
+	Email        string `json:"email"`
+	TeamMemberID string `json:"team_member_id"`
+	TeamName     string `json:"team_name"`
+}
+
+// OriginLogInfo is where the action of a team event came from.
+type OriginLogInfo struct {
+	GeoLocation *struct {
+		City      string `json:"city"`
+		Region    string `json:"region"`
+		Country   string `json:"country"`
+		IPAddress string `json:"ip_address"`
+	} `json:"geo_location"`
+	AccessMethod struct {
+		Tag string `json:".tag"`
+	} `json:"access_method"`
+}
+
+// PathLogInfo is the path of an asset in a team event.
+type PathLogInfo struct {
+	Contextual        string `json:"contextual"`
+	NamespaceRelative struct {
+		NsID              string `json:"ns_id"`
+		RelativePath      string `json:"relative_path"`
+		IsSharedNamespace bool   `json:"is_shared_namespace"`
+	} `json:"namespace_relative"`
+}
+
+// AssetLogInfo is an asset affected by a team event. The tag is "file",
+// "folder", "shared_folder", "paper_document", "paper_folder" or
+// "showcase_document".
+type AssetLogInfo struct {
+	Tag         string       `json:".tag"`
+	Path        *PathLogInfo `json:"path,omitempty"`
+	DisplayName string       `json:"display_name"`
+	FileID      string       `json:"file_id"`
+	DocID       string       `json:"doc_id"`
+	DocTitle    string       `json:"doc_title"`
+}
+
+// ParticipantLogInfo is a user or group involved in a team event.
+type ParticipantLogInfo struct {
+	Tag   string       `json:".tag"`
+	User  *UserLogInfo `json:"user,omitempty"`
+	Group *struct {
+		GroupID     string `json:"group_id"`
+		DisplayName string `json:"display_name"`
+		ExternalID  string `json:"external_id"`
+	} `json:"group,omitempty"`
+}
+
+// TeamEvent is an entry of the team activity log. Details holds the fields
+// specific to the event type, and Raw the event as returned by Dropbox.
+type TeamEvent struct {
+	Timestamp     time.Time `json:"timestamp"`
+	EventCategory struct {
+		Tag string `json:".tag"`
+	} `json:"event_category"`
+	EventType struct {
+		Tag         string `json:".tag"`
+		Description string `json:"description"`
+	} `json:"event_type"`
+	Actor                *ActorLogInfo         `json:"actor"`
+	Context              *ContextLogInfo       `json:"context"`
+	Origin               *OriginLogInfo        `json:"origin"`
+	InvolveNonTeamMember bool                  `json:"involve_non_team_member"`
+	Participants         []*ParticipantLogInfo `json:"participants"`
+	Assets               []*AssetLogInfo       `json:"assets"`
+	Details              json.RawMessage       `json:"details"`
+	Raw                  json.RawMessage       `json:"-"`
+}
+
+// UnmarshalJSON decodes the event and keeps a copy of it in Raw.
+func (e *TeamEvent) UnmarshalJSON(b []byte) error {
+	type event TeamEvent
+	if err := json.Unmarshal(b, (*event)(e)); err != nil {
+		return err
+	}
+	e.Raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// GetTeamEventsOutput request output.
+type GetTeamEventsOutput struct {
+	Events  []*TeamEvent `json:"events"`
+	Cursor  string       `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+}
+
+// GetEvents returns the first page of team activity events, oldest first.
+func (c *Team) GetEvents(ctx context.Context, in *GetTeamEventsInput) (out *GetTeamEventsOutput, err error) {
+	body, err := c.call(ctx, "/team_log/get_events", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// GetTeamEventsContinueInput request input.
+type GetTeamEventsContinueInput struct {
+	Cursor string `json:"cursor"`
+}
+
+// GetEventsContinue returns the next page of team activity events. Once
+// HasMore is false the cursor may be kept to fetch later events.
+func (c *Team) GetEventsContinue(ctx context.Context, in *GetTeamEventsContinueInput) (out *GetTeamEventsOutput, err error) {
+	body, err := c.call(ctx, "/team_log/get_events/continue", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// ExportEvents writes the events matching in to w as JSON lines, in the form
+// returned by Dropbox. When store is not nil the export resumes from the
+// saved cursor, which is replaced after each page is written, so an
+// interrupted export can be restarted without duplicating events.
+func (c *Team) ExportEvents(ctx context.Context, w io.Writer, in *GetTeamEventsInput, store CursorStore) (n int, err error) {
+	var cursor string
+	if store != nil {
+		if cursor, err = store.LoadCursor(ctx); err != nil {
+			return
+		}
+	}
+
+	var line bytes.Buffer
+	it := c.Events(in).From(cursor)
+	for it.Next(ctx) {
+		line.Reset()
+		if err = json.Compact(&line, it.Value().Raw); err != nil {
+			return
+		}
+		line.WriteByte('\n')
+
+		if _, err = w.Write(line.Bytes()); err != nil {
+			return
+		}
+		n++
+
+		if store != nil && it.PageDone() {
+			if err = store.SaveCursor(ctx, it.Cursor()); err != nil {
+				return
+			}
+		}
+	}
+
+	if err = it.Err(); err == nil && store != nil && it.Cursor() != "" {
+		err = store.SaveCursor(ctx, it.Cursor())
+	}
+	return
+}
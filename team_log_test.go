@@ -0,0 +1,119 @@
+package dropbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTeamEventsInput_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(&GetTeamEventsInput{
+		Limit:     100,
+		AccountID: "dbid:1",
+		StartTime: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Category:  TeamEventCategorySharing,
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"limit": 100, "account_id": "dbid:1", "time": {"start_time": "2020-01-02T03:04:05Z"}, "category": {".tag": "sharing"}}`, string(b))
+
+	b, err = json.Marshal(&GetTeamEventsInput{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(b))
+}
+
+// teamLogServer serves two pages of events, then an empty page, and rate
+// limits the first continue request.
+func teamLogServer(t *testing.T, paths *[]string) http.HandlerFunc {
+	limited := false
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		*paths = append(*paths, r.URL.Path+" "+string(b))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/2/team_log/get_events":
+			io.WriteString(w, `{"events": [
+				{"timestamp": "2020-01-01T00:00:00Z", "event_category": {".tag": "sharing"}, "event_type": {".tag": "shared_content_download", "description": "Downloaded shared file"}, "actor": {".tag": "user", "user": {".tag": "team_member", "email": "tobi@example.com"}}, "context": {".tag": "team_member", "team_member_id": "dbmid:1"}, "assets": [{".tag": "file", "path": {"contextual": "/a.txt"}, "display_name": "a.txt"}], "details": {".tag": "shared_content_download_details"}},
+				{"timestamp": "2020-01-01T00:00:01Z", "event_category": {".tag": "logins"}, "event_type": {".tag": "login_success"}}
+			], "cursor": "c1", "has_more": true}`)
+		case strings.Contains(string(b), `"c1"`) && !limited:
+			limited = true
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			io.WriteString(w, `{"error_summary": "too_many_requests/..", "error": {"reason": {".tag": "too_many_requests"}}}`)
+		case strings.Contains(string(b), `"c1"`):
+			io.WriteString(w, `{"events": [{"timestamp": "2020-01-01T00:00:02Z", "event_type": {".tag": "logout"}}], "cursor": "c2", "has_more": false}`)
+		default:
+			io.WriteString(w, `{"events": [], "cursor": "c3", "has_more": false}`)
+		}
+	}
+}
+
+func TestTeam_Events(t *testing.T) {
+	var paths []string
+	c := testClient(t, teamLogServer(t, &paths))
+	c.Retry = testRetryPolicy
+
+	it := c.Team.Events(&GetTeamEventsInput{Category: TeamEventCategorySharing})
+
+	var types []string
+	for it.Next(ctx) {
+		types = append(types, it.Value().EventType.Tag)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"shared_content_download", "login_success", "logout"}, types)
+	assert.Equal(t, "c2", it.Cursor())
+	assert.Len(t, paths, 3, "the rate limited page should be retried")
+
+	it = c.Team.Events(nil)
+	require.True(t, it.Next(ctx))
+	e := it.Value()
+	assert.Equal(t, "user", e.Actor.Tag)
+	assert.Equal(t, "tobi@example.com", e.Actor.User.Email)
+	assert.Equal(t, "dbmid:1", e.Context.TeamMemberID)
+	assert.Equal(t, "/a.txt", e.Assets[0].Path.Contextual)
+	assert.Equal(t, "Downloaded shared file", e.EventType.Description)
+	assert.JSONEq(t, `{".tag": "shared_content_download_details"}`, string(e.Details))
+}
+
+func TestTeam_ExportEvents(t *testing.T) {
+	var paths []string
+	c := testClient(t, teamLogServer(t, &paths))
+	c.Retry = testRetryPolicy
+	store := FileCursorStore(filepath.Join(t.TempDir(), "cursor"))
+
+	var buf bytes.Buffer
+	n, err := c.Team.ExportEvents(ctx, &buf, nil, store)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	var e TeamEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &e))
+	assert.Equal(t, "logout", e.EventType.Tag)
+
+	cursor, err := store.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c2", cursor)
+
+	// A second export resumes from the saved cursor.
+	buf.Reset()
+	paths = nil
+	n, err = c.Team.ExportEvents(ctx, &buf, nil, store)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, []string{`/2/team_log/get_events/continue {"cursor":"c2"}`}, paths)
+
+	cursor, err = store.LoadCursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c3", cursor)
+}
@@ -0,0 +1,183 @@
+package dropbox
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeam_AsMember(t *testing.T) {
+	var headers []http.Header
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/files/download":
+			w.Header().Set("Dropbox-API-Result", `{"name": "hello.txt"}`)
+			io.WriteString(w, "hello")
+		default:
+			io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+		}
+	})
+
+	member := c.Team.AsMember("dbmid:member")
+
+	_, err := member.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+
+	out, err := member.Files.Download(ctx, &DownloadInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	out.Body.Close()
+
+	_, err = c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+
+	require.Len(t, headers, 3)
+	assert.Equal(t, "dbmid:member", headers[0].Get("Dropbox-API-Select-User"))
+	assert.Equal(t, "dbmid:member", headers[1].Get("Dropbox-API-Select-User"))
+	assert.Empty(t, headers[2].Get("Dropbox-API-Select-User"), "the team client should not be changed")
+	assert.Empty(t, c.SelectUser)
+}
+
+func TestTeam_AsAdmin(t *testing.T) {
+	var header http.Header
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"folders": []}`)
+	})
+
+	admin := c.Team.AsAdmin("dbmid:admin")
+	_, err := admin.Sharing.ListSharedFolders(ctx, &ListSharedFolderInput{Limit: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, "dbmid:admin", header.Get("Dropbox-API-Select-Admin"))
+	assert.Empty(t, header.Get("Dropbox-API-Select-User"))
+}
+
+func TestTeam_AsMember_teamRoutes(t *testing.T) {
+	var header http.Header
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"name": "Acme", "team_id": "dbtid:1"}`)
+	})
+
+	for _, member := range []*Client{c.Team.AsMember("dbmid:member"), c.Team.AsAdmin("dbmid:admin")} {
+		_, err := member.Team.GetInfo(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, header.Get("Dropbox-API-Select-User"))
+		assert.Empty(t, header.Get("Dropbox-API-Select-Admin"))
+	}
+}
+
+func TestTeam_ListMembers(t *testing.T) {
+	var paths, bodies []string
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, string(b))
+		assert.Empty(t, r.Header.Get("Dropbox-API-Select-User"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/team/members/list_v2":
+			io.WriteString(w, `{"members": [{"profile": {"team_member_id": "dbmid:1", "email": "tobi@example.com", "status": {".tag": "active"}, "name": {"display_name": "Tobi"}, "joined_on": "2015-05-12T15:50:38Z"}, "roles": [{"role_id": "pid_dbtmr:1", "name": "Team admin"}]}], "cursor": "c1", "has_more": true}`)
+		case "/2/team/members/list/continue_v2":
+			io.WriteString(w, `{"members": [{"profile": {"team_member_id": "dbmid:2"}, "roles": []}], "cursor": "c2", "has_more": false}`)
+		}
+	})
+
+	out, err := c.Team.ListMembers(ctx, &ListTeamMembersInput{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, out.Members, 1)
+	m := out.Members[0]
+	assert.Equal(t, "dbmid:1", m.Profile.TeamMemberID)
+	assert.Equal(t, "tobi@example.com", m.Profile.Email)
+	assert.Equal(t, "active", m.Profile.Status.Tag)
+	assert.Equal(t, "Tobi", m.Profile.Name.DisplayName)
+	assert.Equal(t, 2015, m.Profile.JoinedOn.Year())
+	assert.Equal(t, "Team admin", m.Roles[0].Name)
+	assert.True(t, out.HasMore)
+
+	out, err = c.Team.ListMembersContinue(ctx, &ListTeamMembersContinueInput{Cursor: out.Cursor})
+	require.NoError(t, err)
+	assert.Equal(t, "dbmid:2", out.Members[0].Profile.TeamMemberID)
+	assert.False(t, out.HasMore)
+
+	assert.Equal(t, []string{"/2/team/members/list_v2", "/2/team/members/list/continue_v2"}, paths)
+	assert.JSONEq(t, `{"limit": 1, "include_removed": false}`, bodies[0])
+	assert.JSONEq(t, `{"cursor": "c1"}`, bodies[1])
+}
+
+func TestTeam_GetMembersInfo(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "/2/team/members/get_info_v2", r.URL.Path)
+		assert.JSONEq(t, `{"members": [{".tag": "team_member_id", "team_member_id": "dbmid:1"}, {".tag": "email", "email": "nobody@example.com"}]}`, string(b))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"members_info": [{".tag": "member_info", "profile": {"team_member_id": "dbmid:1"}, "roles": []}, {".tag": "id_not_found", "id_not_found": "nobody@example.com"}]}`)
+	})
+
+	out, err := c.Team.GetMembersInfo(ctx, &GetTeamMembersInfoInput{
+		Members: []UserSelector{SelectTeamMemberID("dbmid:1"), SelectEmail("nobody@example.com")},
+	})
+	require.NoError(t, err)
+	require.Len(t, out.MembersInfo, 2)
+	assert.Equal(t, "member_info", out.MembersInfo[0].Tag)
+	assert.Equal(t, "dbmid:1", out.MembersInfo[0].Profile.TeamMemberID)
+	assert.Equal(t, "id_not_found", out.MembersInfo[1].Tag)
+	assert.Equal(t, "nobody@example.com", out.MembersInfo[1].IDNotFound)
+}
+
+func TestTeam_GetInfo(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/team/get_info", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"name": "Dropbox Inc.", "team_id": "dbtid:1234", "num_licensed_users": 5, "num_provisioned_users": 2, "policies": {"sharing": {"shared_folder_member_policy": {".tag": "team"}}, "emm_state": {".tag": "disabled"}}}`)
+	})
+
+	out, err := c.Team.GetInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Dropbox Inc.", out.Name)
+	assert.Equal(t, "dbtid:1234", out.TeamID)
+	assert.Equal(t, uint32(5), out.NumLicensedUsers)
+	assert.Equal(t, "disabled", out.Policies.EmmState.Tag)
+}
+
+func TestTeam_groupsAndNamespaces(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2/team/groups/list":
+			io.WriteString(w, `{"groups": [{"group_name": "Test group", "group_id": "g:1", "member_count": 1, "group_management_type": {".tag": "user_managed"}}], "cursor": "c", "has_more": false}`)
+		case "/2/team/groups/members/list":
+			assert.JSONEq(t, `{"group": {".tag": "group_id", "group_id": "g:1"}, "limit": 10}`, string(b))
+			io.WriteString(w, `{"members": [{"profile": {"team_member_id": "dbmid:1"}, "access_type": {".tag": "owner"}}], "cursor": "c", "has_more": false}`)
+		case "/2/team/namespaces/list":
+			io.WriteString(w, `{"namespaces": [{"name": "Marketing", "namespace_id": "123", "namespace_type": {".tag": "team_folder"}}], "cursor": "c", "has_more": false}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	groups, err := c.Team.ListGroups(ctx, &ListGroupsInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "Test group", groups.Groups[0].GroupName)
+	assert.Equal(t, "user_managed", groups.Groups[0].GroupManagementType.Tag)
+
+	members, err := c.Team.ListGroupMembers(ctx, &ListGroupMembersInput{
+		Group: SelectGroupID(groups.Groups[0].GroupID),
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "owner", members.Members[0].AccessType.Tag)
+
+	namespaces, err := c.Team.ListNamespaces(ctx, &ListNamespacesInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "team_folder", namespaces.Namespaces[0].NamespaceType.Tag)
+}
@@ -0,0 +1,193 @@
+package dropbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExpiredAccessToken is the error tag returned when an access token has expired.
+const ExpiredAccessToken = "expired_access_token"
+
+// Token is an OAuth2 access token, and for short-lived tokens the refresh
+// token and expiry time.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// expired reports whether the token expires within delta.
+func (t *Token) expired(delta time.Duration) bool {
+	return t == nil || t.AccessToken == "" ||
+		(!t.Expiry.IsZero() && time.Now().Add(delta).After(t.Expiry))
+}
+
+// TokenSource supplies the access token for each request.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenRefresher is implemented by a TokenSource which can replace its token
+// early, for example after Dropbox rejected it as expired.
+type TokenRefresher interface {
+	Refresh(ctx context.Context) (*Token, error)
+}
+
+// StaticTokenSource returns a TokenSource which always returns the given
+// access token.
+func StaticTokenSource(accessToken string) TokenSource {
+	return staticTokenSource{&Token{AccessToken: accessToken}}
+}
+
+type staticTokenSource struct {
+	token *Token
+}
+
+func (s staticTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.token, nil
+}
+
+// RefreshTokenSource is a TokenSource which uses a long-lived refresh token to
+// obtain short-lived access tokens, refreshing them shortly before they expire.
+// AppSecret is left empty for apps which authorized with PKCE.
+type RefreshTokenSource struct {
+	HTTPClient   *http.Client
+	Endpoints    *Endpoints // defaults to DefaultEndpoints when nil
+	AppKey       string
+	AppSecret    string
+	RefreshToken string
+	ExpiryDelta  time.Duration // refresh this long before expiry, defaults to one minute
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewRefreshTokenSource with the given app credentials and refresh token.
+func NewRefreshTokenSource(appKey, appSecret, refreshToken string) *RefreshTokenSource {
+	return &RefreshTokenSource{
+		HTTPClient:   http.DefaultClient,
+		AppKey:       appKey,
+		AppSecret:    appSecret,
+		RefreshToken: refreshToken,
+	}
+}
+
+// Token returns the current access token, refreshing it if it is about to
+// expire.
+func (s *RefreshTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delta := s.ExpiryDelta
+	if delta == 0 {
+		delta = time.Minute
+	}
+
+	if !s.token.expired(delta) {
+		return s.token, nil
+	}
+	return s.refresh(ctx)
+}
+
+// Refresh obtains a new access token regardless of the current token's expiry.
+func (s *RefreshTokenSource) Refresh(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh(ctx)
+}
+
+func (s *RefreshTokenSource) refresh(ctx context.Context) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.RefreshToken},
+		"client_id":     {s.AppKey},
+	}
+	if s.AppSecret != "" {
+		form.Set("client_secret", s.AppSecret)
+	}
+
+	endpoints := s.Endpoints
+	if endpoints == nil {
+		endpoints = DefaultEndpoints
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	token, err := requestToken(ctx, client, endpoints, form)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = s.RefreshToken
+	}
+	s.token = token
+	return token, nil
+}
+
+// TokenError is returned when the OAuth2 token endpoint rejects a request.
+type TokenError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Error string.
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("dropbox: oauth2 %s: %s", e.Code, e.Description)
+	}
+	return fmt.Sprintf("dropbox: oauth2 %s (%d)", e.Code, e.StatusCode)
+}
+
+// requestToken posts the form to the OAuth2 token endpoint.
+func requestToken(ctx context.Context, client *http.Client, endpoints *Endpoints, form url.Values) (*Token, error) {
+	req, err := http.NewRequest("POST", endpoints.API+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		e := &TokenError{StatusCode: res.StatusCode}
+		json.NewDecoder(res.Body).Decode(e)
+		if e.Code == "" {
+			e.Code = http.StatusText(res.StatusCode)
+		}
+		return nil, e
+	}
+
+	var out struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+
+	token := &Token{
+		AccessToken:  out.AccessToken,
+		RefreshToken: out.RefreshToken,
+	}
+	if out.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
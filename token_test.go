@@ -0,0 +1,93 @@
+package dropbox
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer issues numbered access tokens from /oauth2/token and accepts
+// only the latest one on the API routes.
+func tokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	var issued int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/oauth2/token" {
+			assert.Equal(t, "refresh_token", r.FormValue("grant_type"))
+			assert.Equal(t, "refresh", r.FormValue("refresh_token"))
+			assert.Equal(t, "key", r.FormValue("client_id"))
+			n := atomic.AddInt32(&issued, 1)
+			fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": %d}`, n, expiresIn)
+			return
+		}
+
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", atomic.LoadInt32(&issued)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error_summary": "expired_access_token/..", "error": {".tag": "expired_access_token"}}`)
+			return
+		}
+		io.WriteString(w, `{".tag": "file", "name": "hello.txt"}`)
+	}))
+	t.Cleanup(s.Close)
+	return s, &issued
+}
+
+func TestRefreshTokenSource_Token(t *testing.T) {
+	s, issued := tokenServer(t, 14400)
+
+	source := NewRefreshTokenSource("key", "", "refresh")
+	source.Endpoints = NewEndpoints(s.URL)
+
+	token, err := source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(4*time.Hour), token.Expiry, time.Second)
+
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken, "token should be cached until it nears expiry")
+
+	source.ExpiryDelta = 5 * time.Hour
+	token, err = source.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken, "token should be refreshed before it expires")
+	assert.EqualValues(t, 2, atomic.LoadInt32(issued))
+}
+
+func TestClient_refreshExpiredToken(t *testing.T) {
+	s, issued := tokenServer(t, 14400)
+
+	source := NewRefreshTokenSource("key", "secret", "refresh")
+	source.Endpoints = NewEndpoints(s.URL)
+	source.token = &Token{AccessToken: "stale", Expiry: time.Now().Add(time.Hour)}
+
+	config := NewTokenSourceConfig(source)
+	config.Endpoints = NewEndpoints(s.URL)
+	c := New(config)
+
+	out, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello.txt", out.Entry().Name)
+	assert.EqualValues(t, 1, atomic.LoadInt32(issued))
+}
+
+func TestClient_expiredStaticToken(t *testing.T) {
+	s, _ := tokenServer(t, 14400)
+
+	config := NewTokenSourceConfig(StaticTokenSource("stale"))
+	config.Endpoints = NewEndpoints(s.URL)
+	c := New(config)
+
+	_, err := c.Files.GetMetadata(ctx, &GetMetadataInput{Path: "/hello.txt"})
+	require.Error(t, err)
+	assert.Equal(t, 401, err.(*Error).StatusCode)
+}
@@ -0,0 +1,309 @@
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UploadSessionFinishBatchArg is an upload session to commit as part of a batch.
+type UploadSessionFinishBatchArg struct {
+	Cursor UploadSessionCursor `json:"cursor"`
+	Commit CommitInfo          `json:"commit"`
+}
+
+// UploadSessionFinishBatchInput request input. At most 1000 entries may be
+// committed at once, and each session must have been closed by its last
+// append.
+type UploadSessionFinishBatchInput struct {
+	Entries []UploadSessionFinishBatchArg `json:"entries"`
+}
+
+// UploadSessionFinishBatchOutput request output. Tag is "async_job_id" when
+// the batch is still being committed, in which case its entries are fetched
+// with UploadSessionFinishBatchCheck, and "in_progress" or "complete" from
+// the check itself.
+type UploadSessionFinishBatchOutput struct {
+	Tag        string                           `json:".tag"`
+	AsyncJobID string                           `json:"async_job_id"`
+	Entries    []*UploadSessionFinishBatchEntry `json:"entries"`
+}
+
+// UploadSessionFinishBatchEntry is the result of committing one session,
+// either its file Metadata or an Err describing why it failed.
+type UploadSessionFinishBatchEntry struct {
+	Metadata *FileMetadata
+	Err      *Error
+}
+
+// UnmarshalJSON decodes the success or failure union.
+func (e *UploadSessionFinishBatchEntry) UnmarshalJSON(b []byte) error {
+	var entry struct {
+		Tag     string      `json:".tag"`
+		Failure interface{} `json:"failure"`
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return err
+	}
+
+	if entry.Tag == "failure" {
+		e.Err = &Error{
+			Status:     http.StatusText(http.StatusConflict),
+			StatusCode: http.StatusConflict,
+			Route:      "/files/upload_session/finish",
+			Summary:    tagSummary(entry.Failure),
+			Err:        entry.Failure,
+		}
+		return nil
+	}
+
+	e.Metadata = &FileMetadata{}
+	return json.Unmarshal(b, e.Metadata)
+}
+
+// UploadSessionFinishBatch commits many closed upload sessions at once,
+// which takes the namespace's write lock only once for the whole batch.
+func (c *Files) UploadSessionFinishBatch(ctx context.Context, in *UploadSessionFinishBatchInput) (out *UploadSessionFinishBatchOutput, err error) {
+	body, err := c.call(ctx, "/files/upload_session/finish_batch_v2", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// AsyncJobInput identifies an asynchronous job to check on.
+type AsyncJobInput struct {
+	AsyncJobID string `json:"async_job_id"`
+}
+
+// UploadSessionFinishBatchCheck returns the status of an asynchronous
+// UploadSessionFinishBatch job.
+func (c *Files) UploadSessionFinishBatchCheck(ctx context.Context, in *AsyncJobInput) (out *UploadSessionFinishBatchOutput, err error) {
+	body, err := c.call(ctx, "/files/upload_session/finish_batch/check", in)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(&out)
+	return
+}
+
+// maxFinishBatchEntries is the number of sessions Dropbox commits at once.
+const maxFinishBatchEntries = 1000
+
+// UploadBatchInput request input.
+type UploadBatchInput struct {
+	Files        []*UploadInput
+	ChunkSize    int           // defaults to DefaultUploadChunkSize
+	Concurrency  int           // files uploaded at once, defaults to 4
+	PollInterval time.Duration // delay between job checks, defaults to one second
+}
+
+// UploadBatchResult is the outcome of uploading one file of the batch.
+type UploadBatchResult struct {
+	Path     string
+	Metadata *FileMetadata
+	Err      error
+}
+
+// UploadBatchOutput request output, with one result per input file in the
+// same order.
+type UploadBatchOutput struct {
+	Results []*UploadBatchResult
+}
+
+// UploadBatch uploads many files through concurrent upload sessions, then
+// commits them together with UploadSessionFinishBatch and waits for the
+// commit to complete. Failures of individual files are reported in their
+// result, while the returned error is reserved for failures of the batch.
+func (c *Files) UploadBatch(ctx context.Context, in *UploadBatchInput) (out *UploadBatchOutput, err error) {
+	concurrency := in.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	out = &UploadBatchOutput{Results: make([]*UploadBatchResult, len(in.Files))}
+	cursors := make([]UploadSessionCursor, len(in.Files))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, file := range in.Files {
+		out.Results[i] = &UploadBatchResult{Path: file.Path}
+
+		wg.Add(1)
+		go func(i int, file *UploadInput) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			cursors[i], out.Results[i].Err = c.uploadSession(ctx, file, in.ChunkSize)
+		}(i, file)
+	}
+	wg.Wait()
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []int
+	for i, result := range out.Results {
+		if result.Err == nil {
+			pending = append(pending, i)
+		}
+	}
+
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > maxFinishBatchEntries {
+			n = maxFinishBatchEntries
+		}
+
+		batch := &UploadSessionFinishBatchInput{}
+		for _, i := range pending[:n] {
+			batch.Entries = append(batch.Entries, UploadSessionFinishBatchArg{
+				Cursor: cursors[i],
+				Commit: in.Files[i].commitInfo(),
+			})
+		}
+
+		entries, err := c.finishBatch(ctx, batch, in.PollInterval)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entries) != n {
+			return nil, fmt.Errorf("dropbox: finish batch returned %d entries for %d sessions", len(entries), n)
+		}
+
+		for j, i := range pending[:n] {
+			out.Results[i].Metadata = entries[j].Metadata
+			if entries[j].Err != nil {
+				out.Results[i].Err = entries[j].Err
+			}
+		}
+
+		pending = pending[n:]
+	}
+
+	return
+}
+
+// uploadSession sends the file through a new upload session, closing it with
+// the last chunk so it can be committed in a batch. As with UploadLarge, each
+// chunk is sent with its own content hash when in.ContentHash is set, and the
+// hash of the whole file is checked before the session is closed.
+func (c *Files) uploadSession(ctx context.Context, in *UploadInput, chunkSize int) (cursor UploadSessionCursor, err error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultUploadChunkSize
+	}
+	buf := make([]byte, chunkSize)
+
+	var hash *ContentHash
+	if in.ContentHash != "" {
+		hash = NewContentHash()
+	}
+
+	r := uploadBody(in.Reader)
+	n, last, err := readChunk(r, buf)
+	if err != nil {
+		return
+	}
+
+	if err = checkSession(in, hash, buf[:n], last); err != nil {
+		return
+	}
+
+	start, err := c.UploadSessionStart(ctx, &UploadSessionStartInput{
+		Close:       last,
+		ContentHash: chunkHash(hash, buf[:n]),
+		Reader:      bytes.NewReader(buf[:n]),
+	})
+	if err != nil {
+		return
+	}
+
+	cursor = UploadSessionCursor{SessionID: start.SessionID, Offset: uint64(n)}
+
+	for !last {
+		n, last, err = readChunk(r, buf)
+		if err != nil {
+			return
+		}
+
+		if err = checkSession(in, hash, buf[:n], last); err != nil {
+			return
+		}
+
+		err = c.UploadSessionAppend(ctx, &UploadSessionAppendInput{
+			Cursor:      cursor,
+			Close:       last,
+			ContentHash: chunkHash(hash, buf[:n]),
+			Reader:      bytes.NewReader(buf[:n]),
+		})
+		if err != nil {
+			return
+		}
+
+		cursor.Offset += uint64(n)
+	}
+
+	return
+}
+
+// checkSession adds a chunk to the hash of the file, if any, and checks it
+// against the expected content hash once the last chunk has been read.
+func checkSession(in *UploadInput, hash *ContentHash, chunk []byte, last bool) error {
+	if hash == nil {
+		return nil
+	}
+
+	hash.Write(chunk)
+	if !last {
+		return nil
+	}
+	return checkContentHash(in.Path, hash, in.ContentHash)
+}
+
+// finishBatch commits the batch and polls until its entries are available.
+func (c *Files) finishBatch(ctx context.Context, in *UploadSessionFinishBatchInput, interval time.Duration) ([]*UploadSessionFinishBatchEntry, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	out, err := c.UploadSessionFinishBatch(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Tag != "async_job_id" {
+		return out.Entries, nil
+	}
+
+	job := &AsyncJobInput{AsyncJobID: out.AsyncJobID}
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		out, err = c.UploadSessionFinishBatchCheck(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+
+		if out.Tag == "complete" {
+			return out.Entries, nil
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package dropbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles_UploadBatch(t *testing.T) {
+	var mu sync.Mutex
+	sessions := map[string][]byte{}
+	closed := map[string]bool{}
+	checks := 0
+
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/2/files/upload_session/start", "/2/files/upload_session/append_v2":
+			var arg struct {
+				Cursor UploadSessionCursor `json:"cursor"`
+				Close  bool                `json:"close"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(r.Header.Get("Dropbox-API-Arg")), &arg))
+			data, _ := ioutil.ReadAll(r.Body)
+
+			id := arg.Cursor.SessionID
+			if id == "" {
+				id = fmt.Sprintf("session-%d", len(sessions)+1)
+			}
+			assert.Equal(t, uint64(len(sessions[id])), arg.Cursor.Offset)
+			sessions[id] = append(sessions[id], data...)
+			closed[id] = arg.Close
+			fmt.Fprintf(w, `{"session_id": %q}`, id)
+		case "/2/files/upload_session/finish_batch_v2":
+			var in UploadSessionFinishBatchInput
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+			assert.Len(t, in.Entries, 3)
+			for _, entry := range in.Entries {
+				assert.True(t, closed[entry.Cursor.SessionID], "sessions should be closed before finishing")
+			}
+			io.WriteString(w, `{".tag": "async_job_id", "async_job_id": "job"}`)
+		case "/2/files/upload_session/finish_batch/check":
+			checks++
+			if checks == 1 {
+				io.WriteString(w, `{".tag": "in_progress"}`)
+				return
+			}
+			io.WriteString(w, `{".tag": "complete", "entries": [
+				{".tag": "success", "name": "a.txt", "path_lower": "/a.txt", "size": 250},
+				{".tag": "failure", "failure": {".tag": "path", "path": {".tag": "conflict", "conflict": {".tag": "file"}}}},
+				{".tag": "success", "name": "c.txt", "path_lower": "/c.txt", "size": 0}
+			]}`)
+		}
+	})
+
+	out, err := c.Files.UploadBatch(ctx, &UploadBatchInput{
+		Files: []*UploadInput{
+			{Path: "/a.txt", Reader: bytes.NewReader(bytes.Repeat([]byte("a"), 250))},
+			{Path: "/b.txt", Reader: strings.NewReader("b")},
+			{Path: "/c.txt", Reader: strings.NewReader("")},
+		},
+		ChunkSize:    100,
+		Concurrency:  2,
+		PollInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Results, 3)
+
+	assert.NoError(t, out.Results[0].Err)
+	assert.Equal(t, "/a.txt", out.Results[0].Metadata.PathLower)
+	assert.Equal(t, uint64(250), out.Results[0].Metadata.Size)
+
+	require.Error(t, out.Results[1].Err)
+	assert.Equal(t, "/b.txt", out.Results[1].Path)
+	assert.Equal(t, "path/conflict/file/..", out.Results[1].Err.Error())
+	tag, value := out.Results[1].Err.(*Error).Tag()
+	assert.Equal(t, "path", tag)
+	assert.Equal(t, "conflict", value)
+
+	assert.NoError(t, out.Results[2].Err)
+	assert.Equal(t, 2, checks)
+}
+
+func TestFiles_uploadSession_nilReader(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/files/upload_session/start", r.URL.Path)
+		assert.JSONEq(t, `{"close": true}`, r.Header.Get("Dropbox-API-Arg"))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"session_id": "session-1"}`)
+	})
+
+	cursor, err := c.Files.(*Files).uploadSession(ctx, &UploadInput{Path: "/empty.txt"}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, UploadSessionCursor{SessionID: "session-1"}, cursor)
+}
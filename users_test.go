@@ -7,13 +7,13 @@ import (
 )
 
 func TestUsers_GetCurrentAccount(t *testing.T) {
-	c := client()
+	c := client(t)
 	_, err := c.Users.GetCurrentAccount(ctx)
 	assert.NoError(t, err)
 }
 
 func TestUsers_GetAccountBatch(t *testing.T) {
-	c := client()
+	c := client(t)
 	setup, err := c.Users.GetCurrentAccount(ctx)
 
 	assert.NoError(t, err)
@@ -0,0 +1,256 @@
+package dropbox
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// EventType describes how an entry changed.
+type EventType string
+
+// Event types sent by a Watcher.
+const (
+	EventChanged EventType = "changed" // the entry was added or modified
+	EventDeleted EventType = "deleted"
+	EventReset   EventType = "reset" // the cursor expired and the folder is being listed again
+)
+
+// Event is a change to an entry of a watched folder. Metadata is nil for
+// EventReset.
+type Event struct {
+	Type     EventType
+	Metadata Metadata
+}
+
+// CursorStore persists a Watcher's cursor, so a restarted process can pick
+// up where it stopped.
+type CursorStore interface {
+	// LoadCursor returns the saved cursor, or an empty string if none was saved.
+	LoadCursor(ctx context.Context) (string, error)
+	// SaveCursor replaces the saved cursor.
+	SaveCursor(ctx context.Context, cursor string) error
+}
+
+// FileCursorStore is a CursorStore which keeps the cursor in a local file.
+type FileCursorStore string
+
+// LoadCursor reads the cursor from the file, if it exists.
+func (s FileCursorStore) LoadCursor(ctx context.Context) (string, error) {
+	b, err := ioutil.ReadFile(string(s))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(b)), err
+}
+
+// SaveCursor atomically replaces the file with the cursor.
+func (s FileCursorStore) SaveCursor(ctx context.Context, cursor string) error {
+	f, err := ioutil.TempFile(filepath.Dir(string(s)), filepath.Base(string(s)))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(cursor + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), string(s))
+}
+
+// Watcher sends the changes to a folder on a channel, using ListFolder and
+// ListFolderContinue to page through changes and ListFolderLongpoll to wait
+// for more. Dropbox does not distinguish new entries from modified ones, so
+// both are sent as EventChanged.
+type Watcher struct {
+	Files           FilesAPI
+	Input           ListFolderInput // folder to watch and listing options
+	Store           CursorStore     // optional, the cursor is kept in memory when nil
+	Timeout         uint64          // longpoll timeout in seconds
+	IncludeExisting bool            // send the folder's current entries when starting without a cursor
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewWatcher for the folder described by in.
+func NewWatcher(files FilesAPI, in *ListFolderInput, store CursorStore) *Watcher {
+	return &Watcher{
+		Files: files,
+		Input: *in,
+		Store: store,
+	}
+}
+
+// Watch starts watching in the background and returns the channel of events,
+// which is closed when the context is cancelled or watching fails. Call Err
+// after the channel is closed to find out why it stopped.
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		err := w.watch(ctx, events)
+		if ctx.Err() != nil {
+			err = nil
+		}
+
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}()
+
+	return events
+}
+
+// Err returns the error which stopped the watcher, or nil if it stopped
+// because its context was cancelled.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Watcher) watch(ctx context.Context, events chan<- Event) error {
+	cursor := ""
+	if w.Store != nil {
+		var err error
+		if cursor, err = w.Store.LoadCursor(ctx); err != nil {
+			return err
+		}
+	}
+
+	if cursor == "" {
+		var err error
+		if cursor, err = w.start(ctx, events, w.IncludeExisting); err != nil {
+			return err
+		}
+	}
+
+	for {
+		out, err := w.Files.ListFolderContinue(ctx, &ListFolderContinueInput{Cursor: cursor})
+		if isReset(err) {
+			if cursor, err = w.reset(ctx, events); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if cursor, err = w.page(ctx, events, out); err != nil {
+			return err
+		}
+
+		if out.HasMore {
+			continue
+		}
+
+		for {
+			poll, err := w.Files.ListFolderLongpoll(ctx, &ListFolderLongpollInput{
+				Cursor:  cursor,
+				Timeout: w.Timeout,
+			})
+			if isReset(err) {
+				if cursor, err = w.reset(ctx, events); err != nil {
+					return err
+				}
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if poll.Changes {
+				break
+			}
+		}
+	}
+}
+
+// reset sends EventReset and lists the folder again, once Dropbox has expired
+// the cursor.
+func (w *Watcher) reset(ctx context.Context, events chan<- Event) (string, error) {
+	if err := w.send(ctx, events, Event{Type: EventReset}); err != nil {
+		return "", err
+	}
+	return w.start(ctx, events, true)
+}
+
+// isReset reports whether err means the cursor has expired.
+func isReset(err error) bool {
+	e, ok := err.(*Error)
+	return ok && hasTag(e, "reset")
+}
+
+// start lists the folder from the beginning, sending its entries if send is
+// set, and returns the resulting cursor.
+func (w *Watcher) start(ctx context.Context, events chan<- Event, send bool) (string, error) {
+	in := w.Input
+
+	if !send {
+		out, err := w.Files.GetLatestCursor(ctx, &in)
+		if err != nil {
+			return "", err
+		}
+		return out.Cursor, w.save(ctx, out.Cursor)
+	}
+
+	out, err := w.Files.ListFolder(ctx, &in)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		cursor, err := w.page(ctx, events, out)
+		if err != nil || !out.HasMore {
+			return cursor, err
+		}
+
+		if out, err = w.Files.ListFolderContinue(ctx, &ListFolderContinueInput{Cursor: cursor}); err != nil {
+			return "", err
+		}
+	}
+}
+
+// page sends the events for a page of entries, then saves its cursor.
+func (w *Watcher) page(ctx context.Context, events chan<- Event, out *ListFolderOutput) (string, error) {
+	for _, entry := range out.Entries {
+		if err := w.send(ctx, events, w.event(entry)); err != nil {
+			return "", err
+		}
+	}
+	return out.Cursor, w.save(ctx, out.Cursor)
+}
+
+// event for the entry, based on its tag.
+func (w *Watcher) event(entry Metadata) Event {
+	if _, ok := entry.(*DeletedMetadata); ok {
+		return Event{Type: EventDeleted, Metadata: entry}
+	}
+	return Event{Type: EventChanged, Metadata: entry}
+}
+
+func (w *Watcher) send(ctx context.Context, events chan<- Event, event Event) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case events <- event:
+		return nil
+	}
+}
+
+func (w *Watcher) save(ctx context.Context, cursor string) error {
+	if w.Store == nil {
+		return nil
+	}
+	return w.Store.SaveCursor(ctx, cursor)
+}
@@ -0,0 +1,165 @@
+package dropbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	polls := 0
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path + " " + in.Cursor {
+		case "/2/files/list_folder/get_latest_cursor ":
+			io.WriteString(w, `{"cursor": "c0"}`)
+		case "/2/files/list_folder/continue c0":
+			io.WriteString(w, `{"cursor": "c1", "has_more": true, "entries": [
+				{".tag": "file", "name": "a.txt", "path_lower": "/a.txt", "rev": "1"},
+				{".tag": "folder", "name": "b", "path_lower": "/b"}
+			]}`)
+		case "/2/files/list_folder/continue c1":
+			io.WriteString(w, `{"cursor": "c2", "has_more": false, "entries": [
+				{".tag": "file", "name": "a.txt", "path_lower": "/a.txt", "rev": "2"},
+				{".tag": "deleted", "name": "b", "path_lower": "/b"}
+			]}`)
+		case "/2/files/list_folder/longpoll c2":
+			polls++
+			io.WriteString(w, `{"changes": true}`)
+		case "/2/files/list_folder/continue c2":
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{"error_summary": "reset/..", "error": {".tag": "reset"}}`)
+		case "/2/files/list_folder ":
+			io.WriteString(w, `{"cursor": "c3", "has_more": false, "entries": [
+				{".tag": "file", "name": "a.txt", "path_lower": "/a.txt", "rev": "3"}
+			]}`)
+		case "/2/files/list_folder/continue c3":
+			io.WriteString(w, `{"cursor": "c3", "has_more": false, "entries": []}`)
+		case "/2/files/list_folder/longpoll c3":
+			<-r.Context().Done()
+		default:
+			t.Errorf("unexpected request %s %s", r.URL.Path, in.Cursor)
+		}
+	})
+
+	store := FileCursorStore(filepath.Join(t.TempDir(), "cursor"))
+	watcher := NewWatcher(c.Files, &ListFolderInput{Path: "/", Recursive: true}, store)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := watcher.Watch(ctx)
+
+	type change struct {
+		Type EventType
+		Path string
+		Rev  string
+	}
+	var changes []change
+	for event := range events {
+		ch := change{Type: event.Type}
+		if event.Metadata != nil {
+			ch.Path = event.Metadata.Entry().PathLower
+		}
+		if file, ok := event.Metadata.(*FileMetadata); ok {
+			ch.Rev = file.Rev
+		}
+		changes = append(changes, ch)
+		if len(changes) == 6 {
+			cursor, err := store.LoadCursor(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "c3", cursor)
+			cancel()
+		}
+	}
+
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, 1, polls)
+	assert.Equal(t, []change{
+		{EventChanged, "/a.txt", "1"},
+		{EventChanged, "/b", ""},
+		{EventChanged, "/a.txt", "2"},
+		{EventDeleted, "/b", ""},
+		{EventReset, "", ""},
+		{EventChanged, "/a.txt", "3"},
+	}, changes)
+}
+
+func TestWatcher_longpollReset(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path + " " + in.Cursor {
+		case "/2/files/list_folder/get_latest_cursor ":
+			io.WriteString(w, `{"cursor": "c0"}`)
+		case "/2/files/list_folder/continue c0":
+			io.WriteString(w, `{"cursor": "c0", "has_more": false, "entries": []}`)
+		case "/2/files/list_folder/longpoll c0":
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{"error_summary": "reset/..", "error": {".tag": "reset"}}`)
+		case "/2/files/list_folder ":
+			io.WriteString(w, `{"cursor": "c1", "has_more": false, "entries": [
+				{".tag": "file", "name": "a.txt", "path_lower": "/a.txt", "rev": "1"}
+			]}`)
+		case "/2/files/list_folder/continue c1":
+			io.WriteString(w, `{"cursor": "c1", "has_more": false, "entries": []}`)
+		case "/2/files/list_folder/longpoll c1":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.URL.Path, in.Cursor)
+		}
+	})
+
+	watcher := NewWatcher(c.Files, &ListFolderInput{Path: "/"}, nil)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var types []EventType
+	for event := range watcher.Watch(ctx) {
+		types = append(types, event.Type)
+		if len(types) == 2 {
+			cancel()
+		}
+	}
+
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, []EventType{EventReset, EventChanged}, types)
+}
+
+func TestWatcher_resumesFromStore(t *testing.T) {
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2/files/list_folder/continue", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, `{"error_summary": "path/not_found/..", "error": {".tag": "path", "path": {".tag": "not_found"}}}`)
+	})
+
+	store := FileCursorStore(filepath.Join(t.TempDir(), "cursor"))
+	require.NoError(t, store.SaveCursor(ctx, "saved"))
+
+	watcher := NewWatcher(c.Files, &ListFolderInput{Path: "/gone"}, store)
+	for range watcher.Watch(ctx) {
+	}
+
+	require.Error(t, watcher.Err())
+	assert.Equal(t, "path/not_found/..", watcher.Err().Error())
+}